@@ -24,41 +24,126 @@ package xypriv
 
 import "strings"
 
-//abstractResourceStore stores all created abstract resources.
-var abstractResourceStore = map[string]AbstractResourceDetails{}
-
 // AbstractResource returns an existed AbstractResourceDetails or creates one
 // if it doesn't exist before.
 func AbstractResource(name string) AbstractResourceDetails {
-	if _, ok := abstractResourceStore[name]; !ok {
-		abstractResourceStore[name] = AbstractResourceDetails{
+	return defaultEngine.AbstractResource(name)
+}
+
+// AbstractResource returns an existed AbstractResourceDetails of the engine or
+// creates one if it doesn't exist before.
+func (e *Engine) AbstractResource(name string) AbstractResourceDetails {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.resources[name]; !ok {
+		e.resources[name] = AbstractResourceDetails{
+			name:        name,
+			engine:      e,
 			permissions: make(map[string]AccessLevel),
 		}
 	}
 
-	return abstractResourceStore[name]
+	return e.resources[name]
+}
+
+// FindAbstractResource returns the AbstractResourceDetails of privilege manager
+// if it exists.
+func FindAbstractResource(name string) (AbstractResourceDetails, bool) {
+	return defaultEngine.FindAbstractResource(name)
+}
+
+// FindAbstractResource returns the AbstractResourceDetails of the engine if it
+// exists.
+func (e *Engine) FindAbstractResource(name string) (AbstractResourceDetails, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var r, ok = e.resources[name]
+	return r, ok
 }
 
 // AbstractResourceDetails contains information about an abstract resource.
 type AbstractResourceDetails struct {
+	name        string
+	engine      *Engine
 	permissions map[string]AccessLevel
 	context     any
 	owner       Subject
+
+	classification Label
+	integrity      Label
+}
+
+// Name returns the name of resource.
+func (r AbstractResourceDetails) Name() string {
+	return r.name
 }
 
 // SetContext sets the context of resource.
 func (r *AbstractResourceDetails) SetContext(c any) {
 	r.context = c
+	r.update(func(stored *AbstractResourceDetails) { stored.context = c })
+	if r.engine != nil {
+		r.engine.persistResource(r.name)
+		r.engine.publishResource(r.name)
+	}
 }
 
 // SetOwner sets the owner of resource.
 func (r *AbstractResourceDetails) SetOwner(o Subject) {
 	r.owner = o
+	r.update(func(stored *AbstractResourceDetails) { stored.owner = o })
+	if r.engine != nil {
+		r.engine.persistResource(r.name)
+		r.engine.publishResource(r.name)
+	}
+}
+
+// SetClassification sets the security label of resource in BellLaPadula mode.
+func (r *AbstractResourceDetails) SetClassification(l Label) {
+	r.classification = l
+	r.update(func(stored *AbstractResourceDetails) { stored.classification = l })
+	if r.engine != nil {
+		r.engine.persistResource(r.name)
+		r.engine.publishResource(r.name)
+	}
+}
+
+// SetIntegrity sets the integrity label of resource in Biba mode.
+func (r *AbstractResourceDetails) SetIntegrity(l Label) {
+	r.integrity = l
+	r.update(func(stored *AbstractResourceDetails) { stored.integrity = l })
+	if r.engine != nil {
+		r.engine.persistResource(r.name)
+		r.engine.publishResource(r.name)
+	}
 }
 
 // SetPermission sets the access level corresponding to the action.
 func (r *AbstractResourceDetails) SetPermission(l AccessLevel, action ...string) {
-	r.permissions[strings.Join(action, "_")] = l
+	var actions = strings.Join(action, "_")
+	r.setPermission(l, actions)
+
+	if r.engine != nil {
+		r.engine.persistResource(r.name)
+		r.engine.publish(Change{
+			Kind:        PermissionChanged,
+			Resource:    r.name,
+			Action:      actions,
+			AccessLevel: l,
+		})
+	}
+}
+
+// setPermission sets the access level corresponding to the joined actions.
+func (r *AbstractResourceDetails) setPermission(l AccessLevel, actions string) {
+	if r.engine != nil {
+		r.engine.mu.Lock()
+		defer r.engine.mu.Unlock()
+		defer r.engine.policyChanged()
+	}
+	r.permissions[actions] = l
 }
 
 // Context implements Resource interface.
@@ -71,11 +156,45 @@ func (r AbstractResourceDetails) Owner() Subject {
 	return r.owner
 }
 
+// Classification implements Classified interface.
+func (r AbstractResourceDetails) Classification() Label {
+	return r.classification
+}
+
+// Integrity implements IntegrityLabeled interface.
+func (r AbstractResourceDetails) Integrity() Label {
+	return r.integrity
+}
+
 // Permission implements Resource interface.
 func (r AbstractResourceDetails) Permission(action ...string) AccessLevel {
 	var actions = strings.Join(action, "_")
+	if r.engine != nil {
+		r.engine.mu.RLock()
+		defer r.engine.mu.RUnlock()
+	}
+
 	if val, ok := r.permissions[actions]; ok {
+		if r.engine != nil {
+			r.engine.coverage.hitPermission(r.name, actions)
+		}
 		return val
 	}
 	return NotSupport
 }
+
+// update applies f to the stored resource in the engine, so that the change
+// is visible to later calls of AbstractResource.
+func (r *AbstractResourceDetails) update(f func(*AbstractResourceDetails)) {
+	if r.engine == nil {
+		return
+	}
+
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+	if stored, ok := r.engine.resources[r.name]; ok {
+		f(&stored)
+		r.engine.resources[r.name] = stored
+		r.engine.policyChanged()
+	}
+}
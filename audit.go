@@ -0,0 +1,369 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Delegatee outcomes in AuditRecord.
+const (
+	DelegateeNone    = ""
+	DelegateeAllowed = "allowed"
+	DelegateeDenied  = "denied"
+)
+
+// AuditRecord is the record of an access decision.
+type AuditRecord struct {
+	Time        time.Time     `json:"time"`
+	Latency     time.Duration `json:"latency"`
+	Subject     string        `json:"subject"`
+	NilSubject  bool          `json:"nil_subject,omitempty"`
+	Relation    Relation      `json:"relation"`
+	Privilege   Privilege     `json:"privilege"`
+	Resource    string        `json:"resource"`
+	Owner       string        `json:"owner,omitempty"`
+	Context     string        `json:"context"`
+	ContextID   string        `json:"context_id,omitempty"`
+	Action      string        `json:"action"`
+	AccessLevel AccessLevel   `json:"access_level"`
+	Delegatee   string        `json:"delegatee,omitempty"`
+	Allowed     bool          `json:"allowed"`
+	FailedOpen  bool          `json:"failed_open,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	ErrorClass  string        `json:"error_class,omitempty"`
+
+	// Clearance and SubjectIntegrity are the labels of subject, they are nil
+	// if the subject has the lowest labels.
+	Clearance        *Label `json:"clearance,omitempty"`
+	SubjectIntegrity *Label `json:"subject_integrity,omitempty"`
+
+	// Classification and Integrity are the labels of resource, they are nil
+	// if the resource has the lowest labels.
+	Classification *Label `json:"classification,omitempty"`
+	Integrity      *Label `json:"integrity,omitempty"`
+}
+
+// Auditor instances are called after every check.
+type Auditor interface {
+	// Audit receives the record of an access decision. It is called
+	// synchronously, so it should return quickly.
+	Audit(r AuditRecord)
+}
+
+// SetAuditor sets the auditor of the default engine. Pass nil to stop
+// auditing.
+func SetAuditor(a Auditor) {
+	defaultEngine.SetAuditor(a)
+}
+
+// SetAuditor sets the auditor of the engine. Pass nil to stop auditing.
+func (e *Engine) SetAuditor(a Auditor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.auditor = a
+}
+
+// audit sends the record of decision to the auditor of engine.
+func (e *Engine) audit(d Decision, start time.Time) {
+	e.mu.RLock()
+	var auditor = e.auditor
+	e.mu.RUnlock()
+
+	if auditor == nil {
+		return
+	}
+
+	var r = AuditRecord{
+		Time:        start,
+		Latency:     time.Since(start),
+		Subject:     e.idOf(d.Subject),
+		Relation:    d.Relation,
+		Privilege:   d.Privilege,
+		Resource:    e.resourceName(d.Resource),
+		Context:     e.name(d.Context),
+		Action:      strings.Join(d.Action, "_"),
+		AccessLevel: d.AccessLevel,
+		Allowed:     d.Allowed,
+		FailedOpen:  d.FailedOpen,
+	}
+
+	if d.Subject == nil {
+		r.NilSubject = true
+	}
+
+	if d.Owner != nil {
+		r.Owner = e.idOf(d.Owner)
+	}
+
+	if d.Delegated {
+		r.Delegatee = DelegateeDenied
+		if d.DelegateeAllowed {
+			r.Delegatee = DelegateeAllowed
+		}
+	}
+
+	if i, ok := d.Context.(Identified); ok {
+		r.ContextID = i.SubjectID()
+	}
+
+	if s, ok := d.Subject.(Cleared); ok {
+		r.Clearance = labelOf(s.Clearance())
+	}
+	if s, ok := d.Subject.(IntegrityLabeled); ok {
+		r.SubjectIntegrity = labelOf(s.Integrity())
+	}
+	if o, ok := d.Resource.(Classified); ok {
+		r.Classification = labelOf(o.Classification())
+	}
+	if o, ok := d.Resource.(IntegrityLabeled); ok {
+		r.Integrity = labelOf(o.Integrity())
+	}
+
+	if d.Err != nil {
+		r.Error = d.Err.Error()
+		r.ErrorClass = ErrorClass(d.Err)
+	}
+
+	auditor.Audit(r)
+}
+
+// JSONLinesAuditor writes every AuditRecord as a JSON line.
+type JSONLinesAuditor struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewJSONLinesAuditor creates a JSONLinesAuditor writing to w. Use a
+// RotatingFile as w to rotate the log by size.
+func NewJSONLinesAuditor(w io.Writer) *JSONLinesAuditor {
+	return &JSONLinesAuditor{w: w}
+}
+
+// Audit implements Auditor interface.
+func (a *JSONLinesAuditor) Audit(r AuditRecord) {
+	var line, err = json.Marshal(r)
+	if err != nil {
+		a.setErr(err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Write the whole line at once so that it is never split by a rotation.
+	if _, err := a.w.Write(append(line, '\n')); err != nil {
+		a.err = err
+	}
+}
+
+// Err returns the last error when writing records.
+func (a *JSONLinesAuditor) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// setErr sets the last error.
+func (a *JSONLinesAuditor) setErr(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.err = err
+}
+
+// RotatingFile is an io.WriteCloser which rotates the file when its size
+// exceeds the limit. Rotated files are named path.1, path.2, ... from newest
+// to oldest.
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewRotatingFile opens the file at path in append mode. The file is rotated
+// before a write makes its size exceed maxSize, at most maxBackups rotated
+// files are kept.
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		return nil, ConfigurationError.New("maxSize must be positive")
+	}
+
+	var f = &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write implements io.Writer interface.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	var err = f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens the file at path and loads its size.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups, moves the current file to path.1, and opens a new
+// file.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	if f.maxBackups <= 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return f.open()
+	}
+
+	os.Remove(f.backup(f.maxBackups))
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(f.backup(i), f.backup(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := os.Rename(f.path, f.backup(1)); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+// backup returns the path of the i-th rotated file.
+func (f *RotatingFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", f.path, i)
+}
+
+// AsyncAuditor buffers records and sends them to another Auditor in the
+// background.
+type AsyncAuditor struct {
+	next    Auditor
+	block   bool
+	records chan AuditRecord
+	done    chan struct{}
+	once    sync.Once
+	dropped uint64
+}
+
+// NewAsyncAuditor creates an AsyncAuditor with a buffer of size records. When
+// the buffer is full, Audit blocks until there is a free slot if block is
+// true, otherwise the record is dropped.
+func NewAsyncAuditor(next Auditor, size int, block bool) *AsyncAuditor {
+	var a = &AsyncAuditor{
+		next:    next,
+		block:   block,
+		records: make(chan AuditRecord, size),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(a.done)
+		for r := range a.records {
+			a.next.Audit(r)
+		}
+	}()
+
+	return a
+}
+
+// Audit implements Auditor interface. It must not be called after Close.
+func (a *AsyncAuditor) Audit(r AuditRecord) {
+	if a.block {
+		a.records <- r
+		return
+	}
+
+	select {
+	case a.records <- r:
+	default:
+		atomic.AddUint64(&a.dropped, 1)
+	}
+}
+
+// Dropped returns the number of dropped records.
+func (a *AsyncAuditor) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Close flushes all buffered records and stops the background goroutine.
+func (a *AsyncAuditor) Close() {
+	a.once.Do(func() { close(a.records) })
+	<-a.done
+}
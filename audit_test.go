@@ -0,0 +1,153 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleNewJSONLinesAuditor() {
+	var dir, err = os.MkdirTemp("", "xypriv-audit")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "audit.log")
+	file, err := xypriv.NewRotatingFile(path, 600, 2)
+	if err != nil {
+		panic(err)
+	}
+
+	var auditor = xypriv.NewAsyncAuditor(xypriv.NewJSONLinesAuditor(file), 16, true)
+
+	var engine = xypriv.NewEngine()
+	engine.SetAuditor(auditor)
+	var table = engine.AbstractResource("account_table")
+	table.SetPermission(xypriv.HighSecret, "create", "admin")
+
+	var token = xypriv.NewToken()
+	token.AllowAction("create", "admin")
+
+	var admin = roleUser{role: "admin"}
+	var moderator = roleUser{role: "moderator"}
+	engine.Check(admin).Delegate(token).Perform("create", "admin").On(table)
+	engine.Check(moderator).Perform("create", "admin").On(table)
+	engine.Check(admin).Perform("create", "admin").On(table)
+
+	auditor.Close()
+	file.Close()
+
+	for _, p := range []string{path + ".1", path} {
+		f, err := os.Open(p)
+		if err != nil {
+			panic(err)
+		}
+
+		var scanner = bufio.NewScanner(f)
+		for scanner.Scan() {
+			var r xypriv.AuditRecord
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				panic(err)
+			}
+			fmt.Printf("%s: %s %s %s delegatee=%q allowed=%v\n",
+				filepath.Base(p), r.Relation, r.Action, r.Resource, r.Delegatee, r.Allowed)
+		}
+		f.Close()
+	}
+
+	// Output:
+	// audit.log.1: admin create_admin account_table delegatee="allowed" allowed=true
+	// audit.log.1: moderator create_admin account_table delegatee="" allowed=false
+	// audit.log: admin create_admin account_table delegatee="" allowed=true
+}
+
+// printRotatedFiles prints the content of every file in dir.
+func printRotatedFiles(dir string) {
+	var entries, err = os.ReadDir(dir)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		var data, err = os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s: %q\n", entry.Name(), data)
+	}
+}
+
+func ExampleNewRotatingFile() {
+	var dir, err = os.MkdirTemp("", "xypriv-rotate")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Every file holds two lines, the oldest lines are pruned.
+	file, err := xypriv.NewRotatingFile(filepath.Join(dir, "audit.log"), 15, 2)
+	if err != nil {
+		panic(err)
+	}
+	for i := 1; i <= 7; i++ {
+		fmt.Fprintf(file, "line %d\n", i)
+	}
+	file.Close()
+
+	printRotatedFiles(dir)
+
+	// Output:
+	// audit.log: "line 7\n"
+	// audit.log.1: "line 5\nline 6\n"
+	// audit.log.2: "line 3\nline 4\n"
+}
+
+func ExampleNewRotatingFile_noBackups() {
+	var dir, err = os.MkdirTemp("", "xypriv-rotate")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Without backups, the file is truncated instead of rotated.
+	file, err := xypriv.NewRotatingFile(filepath.Join(dir, "audit.log"), 15, 0)
+	if err != nil {
+		panic(err)
+	}
+	for i := 1; i <= 3; i++ {
+		fmt.Fprintf(file, "line %d\n", i)
+	}
+	file.Close()
+
+	printRotatedFiles(dir)
+
+	// Output:
+	// audit.log: "line 3\n"
+}
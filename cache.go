@@ -0,0 +1,303 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheOptions configures a Cache.
+type CacheOptions struct {
+	// RelationTTL is the lifetime of cached relations. Relations are not
+	// cached if it is zero.
+	RelationTTL time.Duration
+
+	// DecisionTTL is the lifetime of cached decisions. Decisions are not
+	// cached if it is zero. Checks with a delegatee are never cached.
+	DecisionTTL time.Duration
+
+	// MaxEntries bounds the number of relations and decisions, the least
+	// recently used entries are evicted first. Zero means no limit.
+	MaxEntries int
+
+	// Key returns the identity of a subject, context, owner, or resource in
+	// cache keys. By default, it is the type and the ID of Identified
+	// objects, or the type and the value of others.
+	Key func(v any) string
+}
+
+// Cache caches relations and decisions of an Engine. It also deduplicates
+// concurrent identical lookups.
+type Cache struct {
+	opts      CacheOptions
+	relations *lruCache
+	decisions *lruCache
+	group     singleflight.Group
+}
+
+// NewCache creates a Cache with options.
+func NewCache(opts CacheOptions) *Cache {
+	if opts.Key == nil {
+		opts.Key = defaultCacheKey
+	}
+
+	return &Cache{
+		opts:      opts,
+		relations: newLRUCache(opts.MaxEntries),
+		decisions: newLRUCache(opts.MaxEntries),
+	}
+}
+
+// SetCache sets the cache of the default engine. Pass nil to disable caching.
+func SetCache(c *Cache) {
+	defaultEngine.SetCache(c)
+}
+
+// SetCache sets the cache of the engine. Pass nil to disable caching.
+func (e *Engine) SetCache(c *Cache) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cache = c
+}
+
+// getCache returns the cache of engine, it may be nil.
+func (e *Engine) getCache() *Cache {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.cache
+}
+
+// Invalidate removes all cached relations and decisions touching v, whether v
+// is the subject, the context, the owner, or the resource.
+func (c *Cache) Invalidate(v any) {
+	var tag = c.opts.Key(v)
+	c.relations.invalidate(tag)
+	c.decisions.invalidate(tag)
+}
+
+// Purge removes all cached relations and decisions.
+func (c *Cache) Purge() {
+	c.relations.purge()
+	c.decisions.purge()
+}
+
+// purgeDecisions removes all cached decisions. It is called when the policy
+// changes.
+func (c *Cache) purgeDecisions() {
+	c.decisions.purge()
+}
+
+// relationsOf returns the cached relations or calls resolve to find them. Failed
+// lookups are not cached. Entries older than the revision of rc are refreshed.
+func (c *Cache) relationsOf(
+	rc revisions, ctx any, subject, owner Subject, resolve func() ([]Relation, error),
+) ([]Relation, error) {
+	if c.opts.RelationTTL <= 0 {
+		return resolve()
+	}
+
+	var tags = []string{c.opts.Key(subject), c.opts.Key(ctx), c.opts.Key(owner)}
+	var key = "relation|" + strings.Join(tags, "|")
+	if val, ok := c.relations.get(key, rc.min); ok {
+		return val.([]Relation), nil
+	}
+
+	var val, err, _ = c.group.Do(rc.flightKey(key), func() (any, error) {
+		var relations, err = resolve()
+		if err == nil {
+			c.relations.add(key, relations, c.opts.RelationTTL, tags, rc.current)
+		}
+		return relations, err
+	})
+
+	return val.([]Relation), err
+}
+
+// decision returns the cached decision or calls decide to evaluate it. Entries
+// older than the revision of rc are refreshed. Canceled, failed open, and
+// unresolved decisions are not cached.
+func (c *Cache) decision(rc revisions, ch *Checker, resource Resource, decide func() Decision) Decision {
+	if c.opts.DecisionTTL <= 0 || ch.delegatee != nil {
+		return decide()
+	}
+
+	var tags = []string{
+		c.opts.Key(ch.subject),
+		c.opts.Key(resource.Context()),
+		c.opts.Key(resource.Owner()),
+		c.opts.Key(resource),
+	}
+	var key = "decision|" + strings.Join(tags, "|") + "|" + strings.Join(ch.action, "_")
+
+	var val, ok = c.decisions.get(key, rc.min)
+	if !ok {
+		val, _, _ = c.group.Do(rc.flightKey(key), func() (any, error) {
+			var d = decide()
+			if !d.FailedOpen && !errors.Is(d.Err, CanceledError) &&
+				!errors.Is(d.Err, ResolutionError) {
+				c.decisions.add(key, d, c.opts.DecisionTTL, tags, rc.current)
+			}
+			return d, nil
+		})
+	}
+
+	var d = val.(Decision)
+	d.Subject = ch.subject
+	d.Resource = resource
+	return d
+}
+
+// defaultCacheKey returns the type and the ID of Identified v, or the type and
+// the value of others.
+func defaultCacheKey(v any) string {
+	switch t := v.(type) {
+	case AbstractResourceDetails:
+		return "AbstractResource:" + t.name
+	case Identified:
+		return fmt.Sprintf("%T#%s", v, t.SubjectID())
+	}
+	return fmt.Sprintf("%T:%v", v, v)
+}
+
+// lruEntry is an entry of lruCache.
+type lruEntry struct {
+	key      string
+	value    any
+	expires  time.Time
+	tags     []string
+	revision uint64
+}
+
+// lruCache is a LRU cache whose entries have TTL and can be invalidated by
+// tags.
+type lruCache struct {
+	mu    sync.Mutex
+	max   int
+	order *list.List
+	items map[string]*list.Element
+	tags  map[string]map[string]struct{}
+}
+
+// newLRUCache creates a lruCache holding at most max entries, zero means no
+// limit.
+func newLRUCache(max int) *lruCache {
+	var c = &lruCache{max: max}
+	c.purge()
+	return c
+}
+
+// get returns the value of key if it is not expired and its revision is not
+// less than minRevision.
+func (c *lruCache) get(key string, minRevision uint64) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var elem, ok = c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	var entry = elem.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	if entry.revision < minRevision {
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// add sets the value of key with ttl, tags, and the revision it is computed
+// at.
+func (c *lruCache) add(key string, value any, ttl time.Duration, tags []string, revision uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+
+	var entry = &lruEntry{
+		key:      key,
+		value:    value,
+		expires:  time.Now().Add(ttl),
+		tags:     tags,
+		revision: revision,
+	}
+	c.items[key] = c.order.PushFront(entry)
+	for _, tag := range tags {
+		if _, ok := c.tags[tag]; !ok {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for c.max > 0 && c.order.Len() > c.max {
+		c.remove(c.order.Back())
+	}
+}
+
+// invalidate removes all entries having the tag.
+func (c *lruCache) invalidate(tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.tags[tag] {
+		if elem, ok := c.items[key]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+// purge removes all entries.
+func (c *lruCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order = list.New()
+	c.items = make(map[string]*list.Element)
+	c.tags = make(map[string]map[string]struct{})
+}
+
+// remove removes the element from cache, the lock must be held.
+func (c *lruCache) remove(elem *list.Element) {
+	var entry = elem.Value.(*lruEntry)
+	c.order.Remove(elem)
+	delete(c.items, entry.key)
+	for _, tag := range entry.tags {
+		delete(c.tags[tag], entry.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
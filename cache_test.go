@@ -0,0 +1,209 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// cacheGroup is the context of group posts.
+type cacheGroup struct {
+	id int
+}
+
+// cacheUser implements Subject interface, it counts the relation lookups.
+type cacheUser struct {
+	id      string
+	lookups *int
+}
+
+// Relation returns "sameGroup" for all users in the group context.
+func (u cacheUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	*u.lookups++
+	if _, ok := ctx.(cacheGroup); ok {
+		return "sameGroup"
+	}
+	return "anyone"
+}
+
+// String returns the identity of user, the default cache key uses it.
+func (u cacheUser) String() string {
+	return u.id
+}
+
+// cachePost implements StaticResource interface.
+type cachePost struct {
+	group cacheGroup
+	owner cacheUser
+}
+
+func (p cachePost) Context() any          { return p.group }
+func (p cachePost) Owner() xypriv.Subject { return p.owner }
+func (p cachePost) String() string        { return "post" }
+func (p cachePost) Permission(action ...string) xypriv.AccessLevel {
+	return xypriv.LowPrivate
+}
+
+// slowUser implements Subject interface, its relation lookups block until
+// release is closed.
+type slowUser struct {
+	lookups *int32
+	started chan struct{}
+	release chan struct{}
+}
+
+// Relation returns "sameGroup" after release is closed.
+func (u slowUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	if atomic.AddInt32(u.lookups, 1) == 1 {
+		close(u.started)
+	}
+	<-u.release
+	return "sameGroup"
+}
+
+// String returns the identity of user, the default cache key uses it.
+func (u slowUser) String() string {
+	return "slow"
+}
+
+func ExampleNewCache() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(cacheGroup{}, "sameGroup", xypriv.LowFamiliar)
+
+	var cache = xypriv.NewCache(xypriv.CacheOptions{
+		RelationTTL: time.Minute,
+		MaxEntries:  100,
+	})
+	engine.SetCache(cache)
+
+	var lookups = 0
+	var alice = cacheUser{id: "alice", lookups: &lookups}
+	var bob = cacheUser{id: "bob", lookups: &lookups}
+	var group7 = cacheGroup{id: 7}
+	var post = cachePost{group: group7, owner: bob}
+
+	engine.Check(alice).Perform("read").On(post)
+	engine.Check(alice).Perform("read").On(post)
+	fmt.Println("lookups:", lookups)
+
+	// Alice leaves group 7.
+	cache.Invalidate(group7)
+	engine.Check(alice).Perform("read").On(post)
+	fmt.Println("lookups:", lookups)
+
+	// Output:
+	// lookups: 1
+	// lookups: 2
+}
+
+func ExampleCacheOptions_ttl() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(cacheGroup{}, "sameGroup", xypriv.LowFamiliar)
+	engine.SetCache(xypriv.NewCache(xypriv.CacheOptions{RelationTTL: 20 * time.Millisecond}))
+
+	var lookups = 0
+	var alice = cacheUser{id: "alice", lookups: &lookups}
+	var post = cachePost{group: cacheGroup{id: 7}, owner: cacheUser{id: "bob", lookups: &lookups}}
+
+	engine.Check(alice).Perform("read").On(post)
+	engine.Check(alice).Perform("read").On(post)
+	fmt.Println("lookups:", lookups)
+
+	time.Sleep(40 * time.Millisecond)
+	engine.Check(alice).Perform("read").On(post)
+	fmt.Println("lookups:", lookups)
+
+	// Output:
+	// lookups: 1
+	// lookups: 2
+}
+
+func ExampleCacheOptions_maxEntries() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(cacheGroup{}, "sameGroup", xypriv.LowFamiliar)
+	engine.SetCache(xypriv.NewCache(xypriv.CacheOptions{
+		RelationTTL: time.Minute,
+		MaxEntries:  1,
+	}))
+
+	var lookups = 0
+	var alice = cacheUser{id: "alice", lookups: &lookups}
+	var carol = cacheUser{id: "carol", lookups: &lookups}
+	var post = cachePost{group: cacheGroup{id: 7}, owner: cacheUser{id: "bob", lookups: &lookups}}
+
+	engine.Check(alice).Perform("read").On(post)
+	engine.Check(alice).Perform("read").On(post)
+	fmt.Println("lookups:", lookups)
+
+	// The relation of carol evicts the one of alice.
+	engine.Check(carol).Perform("read").On(post)
+	engine.Check(alice).Perform("read").On(post)
+	fmt.Println("lookups:", lookups)
+
+	// Output:
+	// lookups: 1
+	// lookups: 3
+}
+
+func ExampleCache_singleflight() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(cacheGroup{}, "sameGroup", xypriv.LowFamiliar)
+	engine.SetCache(xypriv.NewCache(xypriv.CacheOptions{RelationTTL: time.Minute}))
+
+	var lookups int32
+	var user = slowUser{
+		lookups: &lookups,
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	var post = cachePost{group: cacheGroup{id: 7}, owner: cacheUser{id: "bob"}}
+
+	var wg sync.WaitGroup
+	var errs = make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = engine.Check(user).Perform("read").On(post)
+		}(i)
+	}
+
+	// Concurrent checks wait for the first lookup instead of starting their
+	// own ones.
+	<-user.started
+	time.Sleep(20 * time.Millisecond)
+	close(user.release)
+	wg.Wait()
+
+	fmt.Println("lookups:", atomic.LoadInt32(&lookups))
+	fmt.Println(errs[0], errs[9])
+
+	// Output:
+	// lookups: 1
+	// <nil> <nil>
+}
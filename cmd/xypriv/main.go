@@ -0,0 +1,221 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Command xypriv provides tools to inspect xypriv policies.
+//
+// Usage:
+//
+//	xypriv diff [-json] [-log audit-log] <old-policy> <new-policy>
+//	xypriv routes <policy> <routes>
+//	xypriv replay [-json] <policy> <audit-log>
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/xybor-x/xypriv"
+	"github.com/xybor-x/xypriv/xyprivhttp"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	var code int
+	switch os.Args[1] {
+	case "diff":
+		code, err = runDiff(os.Args[2:])
+	case "routes":
+		err = runRoutes(os.Args[2:])
+	case "replay":
+		code, err = runReplay(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "xypriv:", err)
+		os.Exit(2)
+	}
+	os.Exit(code)
+}
+
+// usage prints the usage of command and exits.
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: xypriv diff [-json] [-log audit-log] <old-policy> <new-policy>")
+	fmt.Fprintln(os.Stderr, "       xypriv routes <policy> <routes>")
+	fmt.Fprintln(os.Stderr, "       xypriv replay [-json] <policy> <audit-log>")
+	os.Exit(2)
+}
+
+// runDiff prints the changed decisions between two policy files. If an audit
+// log is given, its recorded decisions are also replayed against the new
+// policy. It returns 1 if there is any change, like diff(1).
+func runDiff(args []string) (int, error) {
+	var flags = flag.NewFlagSet("diff", flag.ExitOnError)
+	var asJSON = flags.Bool("json", false, "print the diff in JSON format")
+	var logPath = flags.String("log", "", "replay the JSON-lines audit log against the new policy")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		usage()
+	}
+
+	oldPolicy, err := readPolicy(flags.Arg(0))
+	if err != nil {
+		return 0, err
+	}
+
+	newPolicy, err := readPolicy(flags.Arg(1))
+	if err != nil {
+		return 0, err
+	}
+
+	var diff = xypriv.Diff(oldPolicy, newPolicy)
+	if *logPath == "" {
+		if *asJSON {
+			err = diff.WriteJSON(os.Stdout)
+		} else {
+			err = diff.WriteText(os.Stdout)
+		}
+		if err != nil || diff.Empty() {
+			return 0, err
+		}
+		return 1, nil
+	}
+
+	report, err := replayFile(*logPath, newPolicy)
+	if err != nil {
+		return 0, err
+	}
+
+	if *asJSON {
+		var encoder = json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(struct {
+			Diff   xypriv.PolicyDiff   `json:"diff"`
+			Replay xypriv.ReplayReport `json:"replay"`
+		}{diff, report})
+	} else if err = diff.WriteText(os.Stdout); err == nil {
+		err = report.WriteText(os.Stdout)
+	}
+
+	if err != nil || (diff.Empty() && report.Empty()) {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// runRoutes prints every route in the JSON routes file with its required
+// access levels in the policy.
+func runRoutes(args []string) error {
+	if len(args) != 2 {
+		usage()
+	}
+
+	policy, err := readPolicy(args[0])
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var routes []xyprivhttp.Route
+	if err := json.NewDecoder(f).Decode(&routes); err != nil {
+		return fmt.Errorf("invalid routes: %w", err)
+	}
+
+	var engine = xypriv.NewEngine()
+	engine.LoadPolicy(policy)
+
+	return xyprivhttp.NewRouteTable(engine, routes...).WriteRequirements(os.Stdout)
+}
+
+// runReplay re-evaluates the JSON-lines audit log against the policy file and
+// prints the changed decisions. It returns 1 if there is any change.
+func runReplay(args []string) (int, error) {
+	var flags = flag.NewFlagSet("replay", flag.ExitOnError)
+	var asJSON = flags.Bool("json", false, "print the report in JSON format")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		usage()
+	}
+
+	policy, err := readPolicy(flags.Arg(0))
+	if err != nil {
+		return 0, err
+	}
+
+	report, err := replayFile(flags.Arg(1), policy)
+	if err != nil {
+		return 0, err
+	}
+
+	if *asJSON {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if report.Empty() {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// replayFile re-evaluates the JSON-lines audit log file against the policy.
+func replayFile(path string, policy *xypriv.Policy) (xypriv.ReplayReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return xypriv.ReplayReport{}, err
+	}
+	defer f.Close()
+
+	var engine = xypriv.NewEngine()
+	engine.LoadPolicy(policy)
+	return xypriv.Replay(f, engine)
+}
+
+// readPolicy reads a JSON policy file.
+func readPolicy(path string) (*xypriv.Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return xypriv.ReadPolicy(f)
+}
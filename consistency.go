@@ -0,0 +1,205 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"strconv"
+	"time"
+)
+
+// ConsistencyToken is an opaque token of a revision of a Broadcaster. Checks
+// requiring a token never use cached entries older than its revision.
+type ConsistencyToken string
+
+// newConsistencyToken returns the token of revision of the broadcaster having
+// the ID.
+func newConsistencyToken(feed string, rev uint64) ConsistencyToken {
+	var b = make([]byte, 8, 8+len(feed))
+	binary.BigEndian.PutUint64(b, rev)
+	b = append(b, feed...)
+	return ConsistencyToken(base64.RawURLEncoding.EncodeToString(b))
+}
+
+// revision returns the broadcaster ID and the revision of token.
+func (t ConsistencyToken) revision() (string, uint64, error) {
+	var b, err = base64.RawURLEncoding.DecodeString(string(t))
+	if err != nil || len(b) < 8 {
+		return "", 0, ConfigurationError.Newf("invalid consistency token %q", string(t))
+	}
+	return string(b[8:]), binary.BigEndian.Uint64(b[:8]), nil
+}
+
+// CurrentConsistencyToken returns the token of the current revision of the
+// default engine.
+func CurrentConsistencyToken() ConsistencyToken {
+	return defaultEngine.ConsistencyToken()
+}
+
+// ConsistencyToken returns the token of the current revision of engine, which
+// includes all changes the engine published or applied.
+func (e *Engine) ConsistencyToken() ConsistencyToken {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return newConsistencyToken(e.feed(), e.revision)
+}
+
+// AtLeast requires the check to see all changes up to the revision of token.
+// Cached relations and decisions older than the revision are refreshed. If the
+// engine follows the Broadcaster of token and hasn't reached the revision yet,
+// the check waits for it until the wait limit of engine passes or the
+// context.Context of check is done. After the wait limit, the check bypasses
+// the cache. Tokens of other broadcasters always bypass the cache. An empty
+// token means no requirement.
+func (c *Checker) AtLeast(token ConsistencyToken) *Checker {
+	c.atLeast = token
+	return c
+}
+
+// SetConsistencyWait sets how long checks of the default engine wait for the
+// revision of a ConsistencyToken.
+func SetConsistencyWait(d time.Duration) {
+	defaultEngine.SetConsistencyWait(d)
+}
+
+// SetConsistencyWait sets how long checks wait for the revision of a
+// ConsistencyToken while the engine follows a Broadcaster. The default is one
+// second.
+func (e *Engine) SetConsistencyWait(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.waitLimit = d
+}
+
+// feed returns the ID of broadcaster whose revisions are counted by engine.
+// The lock must be held.
+func (e *Engine) feed() string {
+	switch {
+	case e.followed != nil:
+		return e.followed.ID()
+	case e.broadcaster != nil:
+		return e.broadcaster.ID()
+	}
+	return ""
+}
+
+// WriteWithToken works like Write, then returns the consistency token of the
+// written tuples.
+func (s BroadcastStore) WriteWithToken(tuples ...Tuple) (ConsistencyToken, error) {
+	if err := s.Store.Write(tuples...); err != nil {
+		return "", err
+	}
+	return newConsistencyToken(s.Broadcaster.ID(), s.publish(RelationAdded, tuples)), nil
+}
+
+// DeleteWithToken works like Delete, then returns the consistency token of
+// the deleted tuples.
+func (s BroadcastStore) DeleteWithToken(tuples ...Tuple) (ConsistencyToken, error) {
+	if err := s.Store.Delete(tuples...); err != nil {
+		return "", err
+	}
+	return newConsistencyToken(s.Broadcaster.ID(), s.publish(RelationRemoved, tuples)), nil
+}
+
+// revisions are the revisions of a check. Cached entries older than min are
+// refreshed, new entries are computed at current.
+type revisions struct {
+	min     uint64
+	current uint64
+}
+
+// flightKey returns the key deduplicating concurrent lookups, lookups
+// requiring a revision don't share older ones.
+func (rc revisions) flightKey(key string) string {
+	if rc.min == 0 {
+		return key
+	}
+	return key + "@" + strconv.FormatUint(rc.min, 10)
+}
+
+// revisionsKey is the context.Context key of revisions.
+type revisionsKey struct{}
+
+// withRevisions returns a copy of ctx carrying rc.
+func withRevisions(ctx context.Context, rc revisions) context.Context {
+	return context.WithValue(ctx, revisionsKey{}, rc)
+}
+
+// revisionsFrom returns the revisions carried by ctx, or the zero ones.
+func revisionsFrom(ctx context.Context) revisions {
+	var rc, _ = ctx.Value(revisionsKey{}).(revisions)
+	return rc
+}
+
+// revisions returns the revisions of a check requiring token. It waits for the
+// revision if the engine follows the Broadcaster of token.
+func (e *Engine) revisions(ctx context.Context, token ConsistencyToken) (revisions, error) {
+	var rc revisions
+	if token == "" {
+		rc.current = e.Revision()
+		return rc, nil
+	}
+
+	var feed, rev, err = token.revision()
+	if err != nil {
+		return rc, err
+	}
+
+	e.mu.RLock()
+	var own, following, limit = e.feed(), e.followed != nil, e.waitLimit
+	e.mu.RUnlock()
+
+	if feed != own {
+		// Revisions of another broadcaster are not comparable, the check
+		// bypasses the cache without marking new entries as fresh for them.
+		rc.current = e.Revision()
+		rc.min = rc.current + 1
+		return rc, nil
+	}
+
+	rc.min = rev
+	if following && rev > 0 {
+		var waitCtx, cancel = context.WithTimeout(ctx, limit)
+		defer cancel()
+		if err := e.WaitRevision(waitCtx, rev); err != nil {
+			if ctx.Err() != nil {
+				return rc, err
+			}
+
+			// The engine lags behind, entries computed now are bypassed by
+			// later checks requiring the same token.
+			rc.current = e.Revision()
+			return rc, nil
+		}
+	}
+
+	rc.current = e.Revision()
+	if rc.current < rc.min {
+		// Relations are resolved from their sources after the cache is
+		// bypassed, so they are at least as new as the required revision.
+		rc.current = rc.min
+	}
+	return rc, nil
+}
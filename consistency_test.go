@@ -0,0 +1,107 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleChecker_AtLeast() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(nil, "friend", xypriv.LowFamiliar)
+	engine.SetCache(xypriv.NewCache(xypriv.CacheOptions{
+		RelationTTL: time.Hour,
+		DecisionTTL: time.Hour,
+	}))
+
+	var store = xypriv.BroadcastStore{
+		Store:       xypriv.NewMemoryStore(),
+		Broadcaster: xypriv.NewMemoryBroadcaster(),
+	}
+	var alice = xypriv.StoreSubject{ID: "alice", Store: store, Engine: engine}
+	var bob = xypriv.StoreSubject{ID: "bob", Store: store, Engine: engine}
+	var post = storePost{author: alice}
+
+	fmt.Println(engine.Check(bob).Perform("read").On(post) == nil)
+
+	var token, _ = store.WriteWithToken(engine.NewTuple(nil, alice, "friend", "bob"))
+	fmt.Println(engine.Check(bob).Perform("read").On(post) == nil)
+	fmt.Println(engine.Check(bob).AtLeast(token).Perform("read").On(post) == nil)
+	fmt.Println(engine.Check(bob).Perform("read").On(post) == nil)
+
+	fmt.Println(engine.Check(bob).AtLeast("invalid").Perform("read").On(post))
+
+	// Output:
+	// false
+	// false
+	// true
+	// true
+	// ConfigurationError: invalid consistency token "invalid"
+}
+
+func ExampleEngine_SetConsistencyWait() {
+	var broadcaster = xypriv.NewMemoryBroadcaster()
+	var leader = xypriv.NewEngine()
+	leader.SetBroadcaster(broadcaster)
+
+	// The follower skips the first revisions, so it never reaches them.
+	var follower = xypriv.NewEngine()
+	var stop = follower.Follow(broadcaster, 100)
+	defer stop()
+	follower.SetConsistencyWait(10 * time.Millisecond)
+
+	leader.AddRelation(nil, "friend", xypriv.LowFamiliar)
+	var token = leader.ConsistencyToken()
+
+	follower.AddRelation(nil, "editor", xypriv.Moderator)
+	var document = follower.AbstractResource("document")
+	document.SetPermission(xypriv.Public, "read")
+
+	// The check bypasses the cache after the wait limit.
+	var d = follower.Check(watchEditor{}).AtLeast(token).Perform("read").Decide(document)
+	fmt.Println(d.Allowed)
+
+	// The check is canceled if its context is done first.
+	follower.SetConsistencyWait(time.Hour)
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	d = follower.Check(watchEditor{}).AtLeast(token).Perform("read").DecideContext(ctx, document)
+	fmt.Println(errors.Is(d.Err, xypriv.CanceledError))
+
+	// Tokens of another broadcaster never wait.
+	var other = xypriv.NewEngine()
+	other.SetBroadcaster(xypriv.NewMemoryBroadcaster())
+	other.AddRelation(nil, "friend", xypriv.LowFamiliar)
+	d = follower.Check(watchEditor{}).AtLeast(other.ConsistencyToken()).Perform("read").Decide(document)
+	fmt.Println(d.Allowed)
+
+	// Output:
+	// true
+	// true
+	// true
+}
@@ -71,37 +71,69 @@ var defaultRelation = map[Relation]Privilege{
 	"self":           Self,
 }
 
-// relationMap stores all contexts and their relations.
-var relationMap = map[string]map[Relation]Privilege{}
-
 // AddRelation adds a relation of context to privilege manager. The context
 // should be a string, struct, or pointer of struct.
 func AddRelation(context any, relation Relation, privilege Privilege) {
-	var cname = getName(context)
+	defaultEngine.AddRelation(context, relation, privilege)
+}
+
+// AddRelation adds a relation of context to the engine. The context should be
+// a string, struct, or pointer of struct.
+func (e *Engine) AddRelation(context any, relation Relation, privilege Privilege) {
+	var cname = e.name(context)
+	relation = Relation(strings.ToLower(string(relation)))
+
+	e.addRelation(cname, relation, privilege)
+	e.persist(func(s Store) error { return s.SaveRelation(cname, relation, privilege) })
+	e.publish(Change{
+		Kind:      PrivilegeChanged,
+		Context:   cname,
+		Relation:  relation,
+		Privilege: privilege,
+	})
+}
+
+// addRelation adds the lower-case relation of context name to the engine.
+func (e *Engine) addRelation(cname string, relation Relation, privilege Privilege) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	if _, ok := relationMap[cname]; !ok {
-		relationMap[cname] = make(map[Relation]Privilege)
+	if _, ok := e.relations[cname]; !ok {
+		e.relations[cname] = make(map[Relation]Privilege)
 	}
 
-	relation = Relation(strings.ToLower(string(relation)))
-	relationMap[cname][relation] = privilege
+	e.relations[cname][relation] = privilege
+	e.policyChanged()
 }
 
-// getPrivilege returns the privilege corresponding to context and relation.
-func getPrivilege(context any, relation Relation) Privilege {
-	var cname = getName(context)
+// getPrivilege returns the privilege corresponding to context and relation. If
+// the relation isn't mapped in the context, the ancestors of context are
+// looked up before the default relations.
+func (e *Engine) getPrivilege(context any, relation Relation) Privilege {
+	relation = Relation(strings.ToLower(string(relation)))
 
-	if cmap, ok := relationMap[cname]; ok || cname == "nil" {
-		relation = Relation(strings.ToLower(string(relation)))
-		if priv, ok := cmap[relation]; ok {
-			return priv
+	var chain = e.contextChain(context)
+	var known = false
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, cname := range chain {
+		if cmap, ok := e.relations[cname]; ok {
+			known = true
+			if priv, ok := cmap[relation]; ok {
+				e.coverage.hitRelation(cname, relation)
+				return priv
+			}
 		}
+	}
 
+	if known || chain[0] == "nil" {
 		if priv, ok := defaultRelation[relation]; ok {
 			return priv
 		}
 
-		panic(XyprivError.Newf("unknown relation %s in context %s", relation, cname))
+		panic(XyprivError.Newf("unknown relation %s in context %s", relation, chain[0]))
 	}
-	panic(XyprivError.Newf("unknown context %s", cname))
+	panic(XyprivError.Newf("unknown context %s", chain[0]))
 }
@@ -0,0 +1,262 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// coverageRecorder counts how many times each policy entry is exercised.
+type coverageRecorder struct {
+	mu          sync.Mutex
+	enabled     bool
+	relations   map[string]int
+	permissions map[string]int
+	rules       map[string]int
+	tokens      map[uint64]*LeastPrivilegeToken
+}
+
+// newCoverageRecorder creates a disabled coverageRecorder.
+func newCoverageRecorder() *coverageRecorder {
+	var c = &coverageRecorder{}
+	c.reset()
+	return c
+}
+
+// reset clears all recorded hits and tracked tokens.
+func (c *coverageRecorder) reset() {
+	c.relations = make(map[string]int)
+	c.permissions = make(map[string]int)
+	c.rules = make(map[string]int)
+	c.tokens = make(map[uint64]*LeastPrivilegeToken)
+}
+
+// EnableCoverage starts recording the hits of relation mappings, abstract
+// resource permissions, and token rules. Only tokens created after calling
+// this function are tracked.
+func EnableCoverage() {
+	defaultEngine.EnableCoverage()
+}
+
+// DisableCoverage stops recording hits. The recorded hits are kept until
+// ResetCoverage is called.
+func DisableCoverage() {
+	defaultEngine.DisableCoverage()
+}
+
+// ResetCoverage clears all recorded hits and tracked tokens.
+func ResetCoverage() {
+	defaultEngine.ResetCoverage()
+}
+
+// Coverage returns the coverage report of the current policy. Relation entries
+// are identified by "context.relation", permission entries by
+// "resource.action", and token rules by "token#id:action.relation.scope".
+func Coverage() CoverageReport {
+	return defaultEngine.Coverage()
+}
+
+// EnableCoverage starts recording the hits of the engine. Only tokens created
+// by Engine.NewToken after calling this method are tracked.
+func (e *Engine) EnableCoverage() {
+	e.coverage.mu.Lock()
+	defer e.coverage.mu.Unlock()
+	e.coverage.enabled = true
+}
+
+// DisableCoverage stops recording hits of the engine.
+func (e *Engine) DisableCoverage() {
+	e.coverage.mu.Lock()
+	defer e.coverage.mu.Unlock()
+	e.coverage.enabled = false
+}
+
+// ResetCoverage clears all recorded hits and tracked tokens of the engine.
+func (e *Engine) ResetCoverage() {
+	e.coverage.mu.Lock()
+	defer e.coverage.mu.Unlock()
+	e.coverage.reset()
+}
+
+// trackToken registers a token so that its unexercised rules can be reported.
+func (c *coverageRecorder) trackToken(t *LeastPrivilegeToken) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.enabled {
+		c.tokens[t.id] = t
+	}
+}
+
+// hitRelation records a hit on the relation mapping of context.
+func (c *coverageRecorder) hitRelation(cname string, relation Relation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.enabled {
+		c.relations[cname+"."+string(relation)]++
+	}
+}
+
+// hitPermission records a hit on the action permission of abstract resource.
+func (c *coverageRecorder) hitPermission(resource, action string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.enabled {
+		c.permissions[resource+"."+action]++
+	}
+}
+
+// hitRule records a hit on a rule of the token.
+func (c *coverageRecorder) hitRule(id uint64, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.enabled {
+		c.rules[tokenRuleKey(id, key)]++
+	}
+}
+
+// tokenRuleKey returns the identifier of a token rule in the coverage report.
+func tokenRuleKey(id uint64, key string) string {
+	return fmt.Sprintf("token#%d:%s", id, key)
+}
+
+// CoverageEntry is the number of hits of a policy entry.
+type CoverageEntry struct {
+	Key  string `json:"key"`
+	Hits int    `json:"hits"`
+}
+
+// CoverageReport contains the hits of all relation mappings, abstract resource
+// permissions, and token rules.
+type CoverageReport struct {
+	Relations   []CoverageEntry `json:"relations"`
+	Permissions []CoverageEntry `json:"permissions"`
+	TokenRules  []CoverageEntry `json:"token_rules"`
+}
+
+// Coverage returns the coverage report of the engine.
+func (e *Engine) Coverage() CoverageReport {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	e.coverage.mu.Lock()
+	defer e.coverage.mu.Unlock()
+
+	var report CoverageReport
+	for cname, cmap := range e.relations {
+		for relation := range cmap {
+			var key = cname + "." + string(relation)
+			report.Relations = append(report.Relations,
+				CoverageEntry{Key: key, Hits: e.coverage.relations[key]})
+		}
+	}
+
+	for name, resource := range e.resources {
+		for action := range resource.permissions {
+			var key = name + "." + action
+			report.Permissions = append(report.Permissions,
+				CoverageEntry{Key: key, Hits: e.coverage.permissions[key]})
+		}
+	}
+
+	for id, token := range e.coverage.tokens {
+		for rule := range token.rules {
+			var key = tokenRuleKey(id, rule)
+			report.TokenRules = append(report.TokenRules,
+				CoverageEntry{Key: key, Hits: e.coverage.rules[key]})
+		}
+	}
+
+	for _, entries := range [][]CoverageEntry{
+		report.Relations, report.Permissions, report.TokenRules} {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Key < entries[j].Key
+		})
+	}
+
+	return report
+}
+
+// Uncovered returns all entries which were never exercised.
+func (r CoverageReport) Uncovered() []CoverageEntry {
+	var result []CoverageEntry
+	for _, entries := range [][]CoverageEntry{
+		r.Relations, r.Permissions, r.TokenRules} {
+		for _, e := range entries {
+			if e.Hits == 0 {
+				result = append(result, e)
+			}
+		}
+	}
+	return result
+}
+
+// WriteText writes the human-readable report to w.
+func (r CoverageReport) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	var sections = []struct {
+		name    string
+		entries []CoverageEntry
+	}{
+		{"relations", r.Relations},
+		{"permissions", r.Permissions},
+		{"token rules", r.TokenRules},
+	}
+
+	for _, s := range sections {
+		var covered = 0
+		for _, e := range s.entries {
+			if e.Hits > 0 {
+				covered++
+			}
+		}
+
+		var percent = 100.0
+		if len(s.entries) > 0 {
+			percent = float64(covered) * 100 / float64(len(s.entries))
+		}
+
+		fmt.Fprintf(&sb, "%s: %d/%d covered (%.1f%%)\n",
+			s.name, covered, len(s.entries), percent)
+		for _, e := range s.entries {
+			var mark = "HIT "
+			if e.Hits == 0 {
+				mark = "MISS"
+			}
+			fmt.Fprintf(&sb, "  %s %6d  %s\n", mark, e.Hits, e.Key)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteJSON writes the report to w in JSON format.
+func (r CoverageReport) WriteJSON(w io.Writer) error {
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -0,0 +1,83 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleCoverage() {
+	xypriv.ResetCoverage()
+	xypriv.EnableCoverage()
+	defer xypriv.DisableCoverage()
+
+	xypriv.AddRelation("coverage", "editor", xypriv.Moderator)
+	xypriv.AddRelation("coverage", "viewer", xypriv.LowFamiliar)
+
+	var document = xypriv.AbstractResource("coverage_document")
+	document.SetContext("coverage")
+	document.SetPermission(xypriv.LowConfidential, "update")
+	document.SetPermission(xypriv.LowPrivate, "read")
+
+	var token = xypriv.NewToken()
+	token.AllowAction("read")
+	token.BanAction("delete")
+
+	var editor = roleUser{role: "editor"}
+	if xypriv.Check(editor).Delegate(token).Perform("read").On(document) == nil {
+		fmt.Println("editor can read the document")
+	}
+
+	var report = xypriv.Coverage()
+	for _, e := range report.Uncovered() {
+		// Strip the token identifier, it depends on how many tokens were
+		// created before.
+		if _, rule, ok := strings.Cut(e.Key, ":"); ok {
+			fmt.Println("uncovered token rule:", rule)
+		} else {
+			fmt.Println("uncovered:", e.Key)
+		}
+	}
+
+	report.TokenRules = nil
+	if err := report.WriteText(os.Stdout); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// editor can read the document
+	// uncovered: coverage.viewer
+	// uncovered: coverage_document.update
+	// uncovered token rule: delete..
+	// relations: 1/2 covered (50.0%)
+	//   HIT       1  coverage.editor
+	//   MISS      0  coverage.viewer
+	// permissions: 1/2 covered (50.0%)
+	//   HIT       1  coverage_document.read
+	//   MISS      0  coverage_document.update
+	// token rules: 0/0 covered (100.0%)
+}
@@ -0,0 +1,77 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+// Decision contains the details of a check.
+type Decision struct {
+	// Subject is the subject who wants to perform the action.
+	Subject Subject
+
+	// Resource is the resource which the action is performed on.
+	Resource Resource
+
+	// Context is the context of resource.
+	Context any
+
+	// Owner is the owner of resource.
+	Owner Subject
+
+	// Action is the action which the subject wants to perform.
+	Action []string
+
+	// Relation is the effective relation of subject over owner in the
+	// context. It is empty if the subject is nil.
+	Relation Relation
+
+	// Relations are all relations of subject over owner in the context. It
+	// has many elements only if the subject is a MultiRelationSubject.
+	Relations []Relation
+
+	// Privilege is the privilege corresponding to the relation.
+	Privilege Privilege
+
+	// Explanation describes the rules, such as cross-context relation
+	// mappings, which changed the privilege.
+	Explanation []string
+
+	// AccessLevel is the access level of resource for the action.
+	AccessLevel AccessLevel
+
+	// Delegated is true if a delegatee was consulted.
+	Delegated bool
+
+	// DelegateeAllowed is the result of the delegatee, it is meaningless if
+	// Delegated is false.
+	DelegateeAllowed bool
+
+	// Allowed is true if the subject can perform the action on resource.
+	Allowed bool
+
+	// FailedOpen is true if the relation or the permission couldn't be
+	// resolved and the check used the defaults of a resource failing open.
+	FailedOpen bool
+
+	// Err is the reason why the subject can't perform the action, it is nil if
+	// Allowed is true.
+	Err error
+}
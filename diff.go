@@ -0,0 +1,257 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DecisionChange is a decision which flips between two policies.
+type DecisionChange struct {
+	Resource string   `json:"resource"`
+	Context  string   `json:"context"`
+	Relation Relation `json:"relation"`
+
+	// Via is the relation of subject in an ancestor context, such as
+	// "orgadmin in org", which is mapped to the resource context. It is empty
+	// if the subject has no relation in ancestor contexts.
+	Via string `json:"via,omitempty"`
+
+	Action string `json:"action"`
+	Before bool   `json:"before"`
+	After  bool   `json:"after"`
+}
+
+// ResourceDiff contains all changed decisions of an abstract resource.
+type ResourceDiff struct {
+	Resource string           `json:"resource"`
+	Changes  []DecisionChange `json:"changes"`
+}
+
+// SkippedResource is an abstract resource whose decisions are not compared.
+type SkippedResource struct {
+	Resource string `json:"resource"`
+	Reason   string `json:"reason"`
+}
+
+// PolicyDiff contains all changed decisions between two policies, grouped by
+// resource, and the resources whose decisions are not compared.
+type PolicyDiff struct {
+	Resources []ResourceDiff    `json:"resources"`
+	Skipped   []SkippedResource `json:"skipped,omitempty"`
+}
+
+// Diff enumerates the decision space of relations and abstract resource
+// actions in both policies, then returns the decisions which flip from allow to
+// deny or the reverse. Relations in ancestor contexts which are mapped to the
+// resource context are also enumerated, their changes are reported only if
+// they differ from the changes without them. Resources in contexts having a
+// lattice other than IntegerLattice are skipped, because Diff only knows the
+// integer order. Resources whose MAC modes, labels, or action modes change are
+// also skipped, because their decisions depend on the labels of subjects.
+func Diff(oldPolicy, newPolicy *Policy) PolicyDiff {
+	var diff PolicyDiff
+
+	for _, name := range unionKeys(oldPolicy.Resources, newPolicy.Resources) {
+		var oldResource = oldPolicy.Resources[name]
+		var newResource = newPolicy.Resources[name]
+
+		var context = newResource.Context
+		if _, ok := newPolicy.Resources[name]; !ok {
+			context = oldResource.Context
+		}
+
+		if reason := skipReason(oldPolicy, newPolicy, oldResource, newResource); reason != "" {
+			diff.Skipped = append(diff.Skipped, SkippedResource{Resource: name, Reason: reason})
+			continue
+		}
+
+		var relations = map[Relation]struct{}{}
+		for relation := range defaultRelation {
+			relations[relation] = struct{}{}
+		}
+		for _, p := range []*Policy{oldPolicy, newPolicy} {
+			for _, ctx := range []string{oldResource.Context, newResource.Context} {
+				for _, cname := range p.contextChain(ctx) {
+					for relation := range p.Relations[cname] {
+						relations[relation] = struct{}{}
+					}
+				}
+			}
+		}
+
+		var sortedRelations = make([]Relation, 0, len(relations))
+		for relation := range relations {
+			sortedRelations = append(sortedRelations, relation)
+		}
+		sort.Slice(sortedRelations, func(i, j int) bool {
+			return sortedRelations[i] < sortedRelations[j]
+		})
+
+		var vias = map[ancestorRelation]struct{}{}
+		for _, p := range []*Policy{oldPolicy, newPolicy} {
+			for _, ctx := range []string{oldResource.Context, newResource.Context} {
+				for _, a := range p.ancestorRelations(ctx) {
+					vias[a] = struct{}{}
+				}
+			}
+		}
+
+		var sortedVias = make([]ancestorRelation, 0, len(vias))
+		for a := range vias {
+			sortedVias = append(sortedVias, a)
+		}
+		sort.Slice(sortedVias, func(i, j int) bool {
+			return sortedVias[i].String() < sortedVias[j].String()
+		})
+
+		var rd = ResourceDiff{Resource: name}
+		for _, action := range unionKeys(oldResource.Permissions, newResource.Permissions) {
+			for _, relation := range sortedRelations {
+				var before = oldPolicy.allow(name, relation, action, nil)
+				var after = newPolicy.allow(name, relation, action, nil)
+				var change = DecisionChange{
+					Resource: name,
+					Context:  context,
+					Relation: relation,
+					Action:   action,
+				}
+
+				if before != after {
+					change.Before, change.After = before, after
+					rd.Changes = append(rd.Changes, change)
+				}
+
+				for i := range sortedVias {
+					var viaBefore = oldPolicy.allow(name, relation, action, &sortedVias[i])
+					var viaAfter = newPolicy.allow(name, relation, action, &sortedVias[i])
+					if viaBefore != viaAfter && (viaBefore != before || viaAfter != after) {
+						change.Via = sortedVias[i].String()
+						change.Before, change.After = viaBefore, viaAfter
+						rd.Changes = append(rd.Changes, change)
+					}
+				}
+			}
+		}
+
+		if len(rd.Changes) > 0 {
+			diff.Resources = append(diff.Resources, rd)
+		}
+	}
+
+	return diff
+}
+
+// skipReason returns why the decisions of resource can't be compared between
+// both policies, or an empty string.
+func skipReason(oldPolicy, newPolicy *Policy, oldResource, newResource PolicyResource) string {
+	for _, p := range []*Policy{oldPolicy, newPolicy} {
+		for _, ctx := range []string{oldResource.Context, newResource.Context} {
+			for _, cname := range p.contextChain(ctx) {
+				if p.hasLattice(cname) {
+					return fmt.Sprintf("context %s has a lattice", cname)
+				}
+			}
+		}
+	}
+
+	if oldPolicy.MAC != newPolicy.MAC {
+		return "MAC modes change"
+	}
+	if newPolicy.MAC == 0 {
+		return ""
+	}
+
+	if recordedLabel(oldResource.Classification).String() != recordedLabel(newResource.Classification).String() ||
+		recordedLabel(oldResource.Integrity).String() != recordedLabel(newResource.Integrity).String() {
+		return "labels change"
+	}
+	for _, action := range unionKeys(oldResource.Permissions, newResource.Permissions) {
+		if oldPolicy.actionMode(action) != newPolicy.actionMode(action) {
+			return fmt.Sprintf("mode of action %s changes", action)
+		}
+	}
+	return ""
+}
+
+// Empty returns true if there is neither changed decision nor skipped
+// resource.
+func (d PolicyDiff) Empty() bool {
+	return len(d.Resources) == 0 && len(d.Skipped) == 0
+}
+
+// WriteText writes the human-readable diff to w.
+func (d PolicyDiff) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	for _, rd := range d.Resources {
+		fmt.Fprintf(&sb, "resource %s:\n", rd.Resource)
+		for _, c := range rd.Changes {
+			var relation = string(c.Relation)
+			if c.Via != "" {
+				relation += " with " + c.Via
+			}
+			fmt.Fprintf(&sb, "  %s %s in context %s: %s -> %s\n",
+				relation, c.Action, c.Context, verdict(c.Before), verdict(c.After))
+		}
+	}
+	for _, sr := range d.Skipped {
+		fmt.Fprintf(&sb, "resource %s: skipped, %s\n", sr.Resource, sr.Reason)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteJSON writes the diff to w in JSON format.
+func (d PolicyDiff) WriteJSON(w io.Writer) error {
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
+
+// verdict returns the human-readable form of a decision.
+func verdict(allow bool) string {
+	if allow {
+		return "allow"
+	}
+	return "deny"
+}
+
+// unionKeys returns the sorted union of keys in both maps.
+func unionKeys[V any](a, b map[string]V) []string {
+	var keys = make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
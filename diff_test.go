@@ -0,0 +1,145 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleDiff() {
+	var oldPolicy, _ = xypriv.ReadPolicy(strings.NewReader(`{
+		"relations": {"nil": {"editor": 7}},
+		"resources": {
+			"account_table": {
+				"context": "nil",
+				"permissions": {"create_admin": 9, "create_mod": 7}
+			}
+		}
+	}`))
+
+	var newPolicy = xypriv.NewPolicy()
+	newPolicy.Relations["nil"] = map[xypriv.Relation]xypriv.Privilege{
+		"editor": xypriv.Moderator,
+	}
+	newPolicy.Resources["account_table"] = xypriv.PolicyResource{
+		Context: "nil",
+		Permissions: map[string]xypriv.AccessLevel{
+			"create_admin": xypriv.HighSecret,
+			"create_mod":   xypriv.HighSecret,
+		},
+	}
+
+	xypriv.Diff(oldPolicy, newPolicy).WriteText(os.Stdout)
+
+	// Output:
+	// resource account_table:
+	//   editor create_mod in context nil: allow -> deny
+	//   localadmin create_mod in context nil: allow -> deny
+	//   moderator create_mod in context nil: allow -> deny
+}
+
+func ExampleDiff_mappings() {
+	var oldPolicy, _ = xypriv.ReadPolicy(strings.NewReader(`{
+		"relations": {"org": {"orgadmin": 9}, "team": {"member": 2}},
+		"parents": {"team": "org"},
+		"mappings": [{"from": "org", "relation": "orgAdmin", "to": "team", "privilege": 8}],
+		"resources": {
+			"team_settings": {"context": "team", "permissions": {"update": 8}}
+		}
+	}`))
+
+	// The new policy drops the mapping, banned subjects are never raised.
+	var newPolicy, _ = xypriv.ReadPolicy(strings.NewReader(`{
+		"relations": {"org": {"orgadmin": 9}, "team": {"member": 2}},
+		"parents": {"team": "org"},
+		"resources": {
+			"team_settings": {"context": "team", "permissions": {"update": 8}}
+		}
+	}`))
+
+	xypriv.Diff(oldPolicy, newPolicy).WriteText(os.Stdout)
+
+	// Output:
+	// resource team_settings:
+	//   anyone with orgadmin in org update in context team: allow -> deny
+	//   highfamiliar with orgadmin in org update in context team: allow -> deny
+	//   localmoderator with orgadmin in org update in context team: allow -> deny
+	//   lowfamiliar with orgadmin in org update in context team: allow -> deny
+	//   mediumfamiliar with orgadmin in org update in context team: allow -> deny
+	//   member with orgadmin in org update in context team: allow -> deny
+	//   moderator with orgadmin in org update in context team: allow -> deny
+	//   topfamiliar with orgadmin in org update in context team: allow -> deny
+}
+
+func ExampleDiff_lattices() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation("vault", "auditor", xypriv.LowFamiliar)
+	engine.SetContextParent("ledger", "vault")
+	var report = engine.AbstractResource("report")
+	report.SetContext("ledger")
+	report.SetPermission(xypriv.LowPrivate, "read")
+	var oldPolicy = engine.Policy()
+
+	// Decisions in contexts having a lattice are not compared.
+	engine.SetLattice("vault", xypriv.NewMLSLattice())
+	var newPolicy = engine.Policy()
+	fmt.Println(newPolicy.Lattices)
+
+	var diff = xypriv.Diff(oldPolicy, newPolicy)
+	diff.WriteText(os.Stdout)
+	fmt.Println(diff.Empty())
+
+	// Output:
+	// [vault]
+	// resource report: skipped, context vault has a lattice
+	// false
+}
+
+func ExampleDiff_mac() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(nil, "operator", xypriv.LowFamiliar)
+	engine.SetMAC(xypriv.BellLaPadula)
+	var report = engine.AbstractResource("report")
+	report.SetPermission(xypriv.LowPrivate, "read")
+	var audit = engine.AbstractResource("audit")
+	audit.SetPermission(xypriv.LowPrivate, "read")
+	var oldPolicy = engine.Policy()
+
+	// Decisions depending on the labels of subjects are not compared.
+	report.SetClassification(xypriv.Label{Level: 2})
+	audit.SetPermission(xypriv.HighPrivate, "read")
+	var newPolicy = engine.Policy()
+
+	xypriv.Diff(oldPolicy, newPolicy).WriteText(os.Stdout)
+
+	// Output:
+	// resource audit:
+	//   lowfamiliar read in context nil: allow -> deny
+	//   mediumfamiliar read in context nil: allow -> deny
+	//   operator read in context nil: allow -> deny
+	// resource report: skipped, labels change
+}
@@ -0,0 +1,419 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// engineCounter generates the origins of engines.
+var engineCounter uint64
+
+// defaultEngine is the engine used by package-level functions.
+var defaultEngine = NewEngine()
+
+// RelationResolver returns the relation of subject over owner in the context.
+type RelationResolver func(ctx any, subject, owner Subject) Relation
+
+// Engine stores relation mappings and abstract resources, then evaluates
+// checks against them. Package-level functions use a default Engine.
+type Engine struct {
+	mu        sync.RWMutex
+	relations map[string]map[Relation]Privilege
+	parents   map[string]any
+	mappings  []mappingRule
+	resources map[string]AbstractResourceDetails
+	coverage  *coverageRecorder
+	resolver  RelationResolver
+	auditor   Auditor
+	metrics   Metrics
+	cache     *Cache
+	failure   FailurePolicy
+	namer     Namer
+	strategy  RelationStrategy
+	lattices  map[string]Lattice
+	mac       MACMode
+	modes     map[string]AccessMode
+
+	shadow       *Engine
+	shadowReport func(live, shadow Decision)
+
+	origin      string
+	broadcaster Broadcaster
+	followed    Broadcaster
+	revision    uint64
+	advanced    chan struct{}
+	revoked     map[string]struct{}
+	waitLimit   time.Duration
+
+	store    Store
+	storeErr error
+	tokens   map[string]*LeastPrivilegeToken
+
+	history History
+	pasts   *lruCache
+	tuples  RelationStore
+}
+
+// NewEngine creates an empty Engine.
+func NewEngine() *Engine {
+	return &Engine{
+		relations: make(map[string]map[Relation]Privilege),
+		parents:   make(map[string]any),
+		lattices:  make(map[string]Lattice),
+		modes:     make(map[string]AccessMode),
+		resources: make(map[string]AbstractResourceDetails),
+		coverage:  newCoverageRecorder(),
+		namer:     SimpleNamer,
+		origin:    fmt.Sprintf("engine#%d", atomic.AddUint64(&engineCounter, 1)),
+		advanced:  make(chan struct{}),
+		revoked:   make(map[string]struct{}),
+		waitLimit: time.Second,
+		tokens:    make(map[string]*LeastPrivilegeToken),
+		pasts:     newLRUCache(maxPastStates),
+	}
+}
+
+// Check returns a Checker with the subject, which is evaluated by the engine.
+func (e *Engine) Check(s Subject) *Checker {
+	return &Checker{engine: e, subject: s}
+}
+
+// SetRelationResolver replaces Subject.Relation with the resolver when the
+// engine finds the relation of subject over owner. It helps to migrate from
+// hand-written Relation methods to another source of relations. Pass nil to
+// use Subject.Relation again.
+func (e *Engine) SetRelationResolver(r RelationResolver) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resolver = r
+}
+
+// SetShadow evaluates the shadow engine in the background after every check of
+// the default engine. The report is called with both decisions if they
+// diverge.
+func SetShadow(shadow *Engine, report func(live, shadow Decision)) {
+	defaultEngine.SetShadow(shadow, report)
+}
+
+// SetShadow evaluates the shadow engine in the background after every check of
+// the engine, the result of check is always decided by the engine. The report
+// is called with both decisions if they diverge. Pass a nil shadow to stop.
+func (e *Engine) SetShadow(shadow *Engine, report func(live, shadow Decision)) {
+	if shadow == e {
+		panic(ConfigurationError.New("an engine can't shadow itself"))
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shadow = shadow
+	e.shadowReport = report
+}
+
+// relationsOf returns the relations of subject over owner in the context. Only
+// MultiRelationSubject may have many relations. Errors are only returned by
+// FallibleSubject.
+func (e *Engine) relationsOf(reqCtx context.Context, ctx any, subject, owner Subject) ([]Relation, error) {
+	if isSelf(subject, owner) {
+		return []Relation{"self"}, nil
+	}
+
+	e.mu.RLock()
+	var resolver, cache, tuples = e.resolver, e.cache, e.tuples
+	e.mu.RUnlock()
+
+	if s, ok := subject.(StoreSubject); ok && tuples != nil {
+		subject = s.at(e, tuples)
+	}
+
+	var resolve = func() ([]Relation, error) {
+		if resolver != nil {
+			return []Relation{resolver(ctx, subject, owner)}, nil
+		}
+
+		switch s := subject.(type) {
+		case FallibleSubject:
+			var relation Relation
+			var err = e.retry(reqCtx, func() (err error) {
+				relation, err = s.TryRelation(ctx, owner)
+				return err
+			})
+			return []Relation{relation}, err
+		case FallibleMultiRelationSubject:
+			var relations []Relation
+			var err = e.retry(reqCtx, func() (err error) {
+				relations, err = s.TryRelations(ctx, owner)
+				return err
+			})
+			return relations, err
+		case MultiRelationSubject:
+			return s.Relations(ctx, owner), nil
+		case SubjectWithContext:
+			return []Relation{s.RelationWithContext(reqCtx, ctx, owner)}, nil
+		default:
+			return []Relation{subject.Relation(ctx, owner)}, nil
+		}
+	}
+
+	if cache != nil {
+		return cache.relationsOf(revisionsFrom(reqCtx), ctx, subject, owner, resolve)
+	}
+	return resolve()
+}
+
+// permission returns the access level of resource for the action. Errors are
+// only returned by fallible resources.
+func (e *Engine) permission(reqCtx context.Context, c *Checker, resource Resource) (AccessLevel, error) {
+	var level AccessLevel
+	switch t := resource.(type) {
+	case FallibleStaticResource:
+		return level, e.retry(reqCtx, func() (err error) {
+			level, err = t.TryPermission(c.action...)
+			return err
+		})
+	case FallibleDynamicResource:
+		return level, e.retry(reqCtx, func() (err error) {
+			level, err = t.TryPermission(c.subject, c.action...)
+			return err
+		})
+	case DynamicResourceWithContext:
+		return t.PermissionWithContext(reqCtx, c.subject, c.action...), nil
+	case StaticResource:
+		return t.Permission(c.action...), nil
+	case DynamicResource:
+		return t.Permission(c.subject, c.action...), nil
+	default:
+		panic(NotImplementedError.New(
+			"expected an object implementing Resource or EnhancedResource"))
+	}
+}
+
+// policyChanged is called after the policy of engine changes, the lock must be
+// held.
+func (e *Engine) policyChanged() {
+	if e.cache != nil {
+		e.cache.purgeDecisions()
+	}
+	e.pasts.purge()
+}
+
+// decide evaluates the check of Checker on resource. The check is stopped
+// with a CanceledError if reqCtx is done.
+func (e *Engine) decide(reqCtx context.Context, c *Checker, resource Resource) Decision {
+	var d = Decision{
+		Subject:  c.subject,
+		Resource: resource,
+		Action:   c.action,
+	}
+
+	if err := reqCtx.Err(); err != nil {
+		d.Err = canceled(err)
+		return d
+	}
+
+	var metrics = e.getMetrics()
+
+	d.Context = resource.Context()
+	d.Owner = resource.Owner()
+
+	var start = time.Now()
+	var err error
+	d.AccessLevel, err = e.permission(reqCtx, c, resource)
+	if metrics != nil {
+		metrics.ObservePermission(time.Since(start))
+	}
+	if err != nil {
+		if err := reqCtx.Err(); err != nil {
+			d.Err = canceled(err)
+			return d
+		}
+		if !e.fail(&d, err) {
+			return d
+		}
+		d.AccessLevel = Public
+	}
+
+	if d.Context == d.Owner && d.Owner != nil {
+		panic(XyprivError.New("do not use the owner as the context, you " +
+			"should set the context as nil in this case"))
+	}
+
+	d.Privilege = Anyone
+	if c.subject != nil {
+		start = time.Now()
+		d.Relations, err = e.relationsOf(reqCtx, d.Context, c.subject, d.Owner)
+		if err := reqCtx.Err(); err != nil {
+			d.Err = canceled(err)
+			return d
+		}
+		if err != nil {
+			if !e.fail(&d, err) {
+				return d
+			}
+			d.Relations = nil
+		}
+
+		e.combine(&d)
+		if err := e.applyMappings(reqCtx, &d); err != nil {
+			if err := reqCtx.Err(); err != nil {
+				d.Err = canceled(err)
+				return d
+			}
+			if !e.fail(&d, err) {
+				return d
+			}
+		}
+		if metrics != nil {
+			metrics.ObserveRelation(time.Since(start))
+		}
+
+		if c.delegatee != nil {
+			d.Delegated = true
+			if b, ok := c.delegatee.(SubjectBinder); ok && !bound(b, c.subject) {
+				d.Err = PermissionError.Newf("the token of %s is bound to another subject",
+					e.idOf(c.subject))
+				return d
+			}
+
+			if r, ok := c.delegatee.(Revocable); ok && e.isRevoked(r.TokenID()) {
+				d.Err = PermissionError.Newf("the token %s is revoked", r.TokenID())
+				return d
+			}
+
+			if dc, ok := c.delegatee.(DelegateeWithContext); ok {
+				d.DelegateeAllowed = dc.DelegateWithContext(reqCtx, d.Relation, resource, c.action...)
+			} else {
+				d.DelegateeAllowed = c.delegatee.Delegate(d.Relation, resource, c.action...)
+			}
+			if err := reqCtx.Err(); err != nil {
+				d.Err = canceled(err)
+				return d
+			}
+			if !d.DelegateeAllowed {
+				d.Err = e.denied(c, resource)
+				return d
+			}
+		}
+	}
+
+	if !e.lattice(d.Context).Dominates(d.Privilege, d.AccessLevel) {
+		d.Err = e.denied(c, resource)
+		return d
+	}
+
+	if err := e.checkMAC(c, resource); err != nil {
+		d.Err = err
+		return d
+	}
+
+	d.Allowed = true
+	return d
+}
+
+// observe sends the decision to the metrics of engine.
+func (e *Engine) observe(d Decision) {
+	if metrics := e.getMetrics(); metrics != nil {
+		metrics.ObserveDecision(e.resourceName(d.Resource), strings.Join(d.Action, "_"), d.Allowed)
+	}
+}
+
+// observePanic counts the panic of a check, then continues panicking.
+func (e *Engine) observePanic() {
+	if r := recover(); r != nil {
+		if metrics := e.getMetrics(); metrics != nil {
+			metrics.ObservePanic()
+		}
+		panic(r)
+	}
+}
+
+// runShadow evaluates the shadow engine in the background and reports the
+// divergence from the live decision.
+func (e *Engine) runShadow(c *Checker, resource Resource, live Decision) {
+	e.mu.RLock()
+	var shadow, report = e.shadow, e.shadowReport
+	e.mu.RUnlock()
+
+	if shadow == nil {
+		return
+	}
+
+	var checker = *c
+	checker.engine = shadow
+	go func() {
+		var d = shadow.safeDecide(context.Background(), &checker, shadow.shadowResource(resource))
+		if d.Allowed != live.Allowed && report != nil {
+			report(live, d)
+		}
+	}()
+}
+
+// safeDecide works like decide, but converts panics to the error of Decision.
+func (e *Engine) safeDecide(reqCtx context.Context, c *Checker, resource Resource) (d Decision) {
+	defer func() {
+		if r := recover(); r != nil {
+			d = Decision{
+				Subject:  c.subject,
+				Resource: resource,
+				Action:   c.action,
+				Err:      XyprivError.Newf("%v", r),
+			}
+		}
+	}()
+
+	return e.decide(reqCtx, c, resource)
+}
+
+// shadowResource returns the abstract resource of the engine having the same
+// name if resource is an abstract resource of another engine.
+func (e *Engine) shadowResource(resource Resource) Resource {
+	var r, ok = resource.(AbstractResourceDetails)
+	if !ok || r.engine == e {
+		return resource
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if stored, ok := e.resources[r.name]; ok {
+		return stored
+	}
+
+	return AbstractResourceDetails{
+		name:    r.name,
+		engine:  e,
+		context: r.context,
+		owner:   r.owner,
+	}
+}
+
+// denied returns the PermissionError of Checker on resource.
+func (e *Engine) denied(c *Checker, resource Resource) error {
+	return PermissionError.Newf(
+		"%s do not have the permission to %s %s",
+		e.idOf(c.subject), strings.Join(c.action, "_"), e.resourceName(resource))
+}
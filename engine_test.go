@@ -0,0 +1,61 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleEngine_SetShadow() {
+	var live = xypriv.NewEngine()
+	live.AddRelation(nil, "editor", xypriv.Moderator)
+	var liveDoc = live.AbstractResource("document")
+	liveDoc.SetPermission(xypriv.LowConfidential, "update")
+
+	// The candidate policy downgrades editors.
+	var candidate = xypriv.NewEngine()
+	candidate.AddRelation(nil, "editor", xypriv.MediumFamiliar)
+	var candidateDoc = candidate.AbstractResource("document")
+	candidateDoc.SetPermission(xypriv.LowConfidential, "update")
+
+	var divergences = make(chan [2]xypriv.Decision)
+	live.SetShadow(candidate, func(l, s xypriv.Decision) {
+		divergences <- [2]xypriv.Decision{l, s}
+	})
+
+	var editor = roleUser{role: "editor"}
+	if live.Check(editor).Perform("update").On(liveDoc) == nil {
+		fmt.Println("editor can update the document")
+	}
+
+	var d = <-divergences
+	fmt.Printf("live: %v with privilege %d\n", d[0].Allowed, d[0].Privilege)
+	fmt.Printf("shadow: %v with privilege %d\n", d[1].Allowed, d[1].Privilege)
+
+	// Output:
+	// editor can update the document
+	// live: true with privilege 7
+	// shadow: false with privilege 3
+}
@@ -22,13 +22,60 @@
 
 package xypriv
 
-import "github.com/xybor-x/xyerror"
+import (
+	"errors"
+	"strings"
+
+	"github.com/xybor-x/xyerror"
+)
 
 // Xypriv errors.
 var (
-	XyprivError         = xyerror.NewException("XyprivError")
-	ConfigurationError  = XyprivError.NewException("ConfigurationError")
-	ResourceError       = XyprivError.NewException("ResourceError")
-	PermissionError     = XyprivError.NewException("PermissionError")
-	NotImplementedError = XyprivError.NewException("NotImplementError")
+	XyprivError          = xyerror.NewException("XyprivError")
+	ConfigurationError   = XyprivError.NewException("ConfigurationError")
+	ResourceError        = XyprivError.NewException("ResourceError")
+	PermissionError      = XyprivError.NewException("PermissionError")
+	NotImplementedError  = XyprivError.NewException("NotImplementError")
+	UnauthenticatedError = XyprivError.NewException("UnauthenticatedError")
+	CanceledError        = XyprivError.NewException("CanceledError")
+	ResolutionError      = PermissionError.NewException("ResolutionError")
+	MACError             = PermissionError.NewException("MACError")
 )
+
+// errorClasses are the error classes of xypriv, children come before their
+// parents.
+var errorClasses = []xyerror.Exception{
+	MACError,
+	ResolutionError,
+	CanceledError,
+	UnauthenticatedError,
+	PermissionError,
+	ResourceError,
+	ConfigurationError,
+	NotImplementedError,
+	XyprivError,
+}
+
+// ErrorClass returns the name of the most specific xypriv error class of err,
+// or an empty string if err is not a xypriv error. It is used to send errors
+// across process boundaries.
+func ErrorClass(err error) string {
+	for _, class := range errorClasses {
+		if errors.Is(err, class) {
+			return class.Error()
+		}
+	}
+	return ""
+}
+
+// NewClassError rebuilds the error of the class named by ErrorClass from its
+// message. The "Class: " prefix of msg is trimmed. Unknown classes are
+// rebuilt as XyprivError.
+func NewClassError(class, msg string) error {
+	for _, c := range errorClasses {
+		if c.Error() == class {
+			return c.New(strings.TrimPrefix(msg, class+": "))
+		}
+	}
+	return XyprivError.New(msg)
+}
@@ -0,0 +1,60 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleErrorClass() {
+	var err error = xypriv.UnauthenticatedError.New("missing credentials")
+	var class = xypriv.ErrorClass(err)
+	fmt.Println(class)
+
+	// The error is sent as a string along with its class, then rebuilt.
+	var rebuilt = xypriv.NewClassError(class, err.Error())
+	fmt.Println(rebuilt)
+	fmt.Println(errors.Is(rebuilt, xypriv.UnauthenticatedError), errors.Is(rebuilt, xypriv.PermissionError))
+
+	fmt.Println(xypriv.ErrorClass(xypriv.CanceledError.New("context canceled")))
+
+	// Children are preferred over their parents.
+	fmt.Println(xypriv.ErrorClass(xypriv.ResolutionError.New("store is unavailable")))
+	fmt.Println(xypriv.ErrorClass(xypriv.MACError.New("alice can't read down")))
+
+	fmt.Println(xypriv.ErrorClass(errors.New("unknown")) == "")
+	fmt.Println(xypriv.NewClassError("", "unknown"))
+
+	// Output:
+	// UnauthenticatedError
+	// UnauthenticatedError: missing credentials
+	// true false
+	// CanceledError
+	// ResolutionError
+	// MACError
+	// true
+	// XyprivError: unknown
+}
@@ -0,0 +1,219 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FailMode decides the result of a check when the relation or the permission
+// can't be resolved.
+type FailMode int
+
+// Fail modes.
+const (
+	// FailClosed denies the check with a ResolutionError.
+	FailClosed FailMode = iota
+
+	// FailOpen replaces the unresolvable input with a permissive default, the
+	// access level becomes Public and the relation becomes "anyone". Other
+	// checks, such as delegatees, lattices and MAC, still apply.
+	FailOpen
+)
+
+// FailurePolicy configures how the engine handles errors of FallibleSubject,
+// FallibleStaticResource, and FallibleDynamicResource.
+type FailurePolicy struct {
+	// Mode is the fail mode of all resources.
+	Mode FailMode
+
+	// Resources overrides the fail mode of resources, keyed by the resource
+	// name. Abstract resources are named by their registered names.
+	Resources map[string]FailMode
+
+	// Retries is the number of retries after the first failed attempt.
+	Retries int
+
+	// Backoff is the delay between attempts.
+	Backoff time.Duration
+
+	// Breaker stops calling the resolvers while they keep failing. It is
+	// optional.
+	Breaker *CircuitBreaker
+}
+
+// SetFailurePolicy sets the failure policy of the default engine.
+func SetFailurePolicy(p FailurePolicy) {
+	defaultEngine.SetFailurePolicy(p)
+}
+
+// SetFailurePolicy sets the failure policy of the engine. By default, the
+// engine fails closed without retries.
+func (e *Engine) SetFailurePolicy(p FailurePolicy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failure = p
+}
+
+// getFailurePolicy returns the failure policy of engine.
+func (e *Engine) getFailurePolicy() FailurePolicy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.failure
+}
+
+// retry calls f until it succeeds, the retries are exhausted, the breaker is
+// open, or reqCtx is done.
+func (e *Engine) retry(reqCtx context.Context, f func() error) error {
+	var policy = e.getFailurePolicy()
+
+	var err error
+	for attempt := 0; attempt <= policy.Retries; attempt++ {
+		if attempt > 0 && policy.Backoff > 0 {
+			var timer = time.NewTimer(policy.Backoff)
+			select {
+			case <-reqCtx.Done():
+				timer.Stop()
+				return reqCtx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if policy.Breaker != nil && !policy.Breaker.Allow() {
+			return ResolutionError.New("circuit breaker is open")
+		}
+
+		if err = f(); err == nil {
+			if policy.Breaker != nil {
+				policy.Breaker.Success()
+			}
+			return nil
+		}
+
+		if policy.Breaker != nil {
+			policy.Breaker.Failure()
+		}
+	}
+
+	return err
+}
+
+// fail handles a resolution error according to the fail mode of resource. It
+// returns true if the resource fails open, then the caller substitutes the
+// default of the unresolvable input and continues the check. Otherwise, the
+// error of decision is set.
+func (e *Engine) fail(d *Decision, err error) bool {
+	var policy = e.getFailurePolicy()
+
+	var mode = policy.Mode
+	if m, ok := policy.Resources[e.resourceName(d.Resource)]; ok {
+		mode = m
+	}
+
+	if mode == FailOpen {
+		d.FailedOpen = true
+		return true
+	}
+
+	d.Err = ResolutionError.New(fmt.Sprintf("can't resolve the check of %s on %s: ",
+		e.idOf(d.Subject), e.resourceName(d.Resource)), err)
+	return false
+}
+
+// CircuitBreaker stops calling a flaky resolver after many consecutive
+// failures, then lets a trial call through after a cooldown.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probedAt  time.Time
+	probing   bool
+	now       func() time.Time
+}
+
+// NewCircuitBreaker creates a CircuitBreaker which opens after threshold
+// consecutive failures and stays open during cooldown.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		panic(ConfigurationError.New("threshold must be positive"))
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow returns false if the breaker is open.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+
+	var now = b.now()
+	if now.Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+
+	// After the cooldown, the breaker is half-open and lets exactly one trial
+	// call through. Its result closes or opens the breaker again. A trial call
+	// which never reports is replaced by another one after the cooldown.
+	if b.probing && now.Sub(b.probedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	b.probedAt = now
+	return true
+}
+
+// Open returns true if the breaker is open or half-open.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
+
+// Success closes the breaker.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure records a failure, the breaker is opened when the failures reach
+// the threshold.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
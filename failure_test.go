@@ -0,0 +1,211 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// flakyUser implements FallibleSubject interface. Its relation store fails a
+// number of times before answering.
+type flakyUser struct {
+	failures *int
+}
+
+// Relation panics because the engine always prefers TryRelation.
+func (u flakyUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	panic("unreachable")
+}
+
+// TryRelation returns an error while the store is failing.
+func (u flakyUser) TryRelation(ctx any, subject xypriv.Subject) (xypriv.Relation, error) {
+	if *u.failures > 0 {
+		*u.failures--
+		return "", errors.New("store is unavailable")
+	}
+	return "admin", nil
+}
+
+func ExampleEngine_SetFailurePolicy() {
+	var engine = xypriv.NewEngine()
+	var table = engine.AbstractResource("account_table")
+	table.SetPermission(xypriv.HighSecret, "delete")
+	var feed = engine.AbstractResource("public_feed")
+	feed.SetPermission(xypriv.Public, "read")
+
+	var breaker = xypriv.NewCircuitBreaker(3, time.Hour)
+	engine.SetFailurePolicy(xypriv.FailurePolicy{
+		Mode:      xypriv.FailClosed,
+		Resources: map[string]xypriv.FailMode{"public_feed": xypriv.FailOpen},
+		Retries:   1,
+		Breaker:   breaker,
+	})
+
+	var failures = 1
+	var user = flakyUser{failures: &failures}
+
+	// The first attempt fails, the retry succeeds.
+	fmt.Println(engine.Check(user).Perform("delete").On(table) == nil)
+
+	// Both attempts fail, the check fails closed.
+	failures = 2
+	var err = engine.Check(user).Perform("delete").On(table)
+	fmt.Println(errors.Is(err, xypriv.ResolutionError), errors.Is(err, xypriv.PermissionError))
+
+	// The third consecutive failure opens the breaker, the feed fails open.
+	failures = 1
+	var d = engine.Check(user).Perform("read").Decide(feed)
+	fmt.Println(d.Allowed, d.FailedOpen, breaker.Open())
+
+	// The store is healthy now, but the breaker is still open.
+	err = engine.Check(user).Perform("delete").On(table)
+	fmt.Println(err)
+
+	// Output:
+	// true
+	// true true
+	// true true true
+	// ResolutionError: can't resolve the check of flakyUser on account_table: circuit breaker is open
+}
+
+func ExampleFailurePolicy_cache() {
+	var engine = xypriv.NewEngine()
+	engine.SetFailurePolicy(xypriv.FailurePolicy{Mode: xypriv.FailOpen})
+	engine.SetCache(xypriv.NewCache(xypriv.CacheOptions{DecisionTTL: time.Minute}))
+	var feed = engine.AbstractResource("feed")
+	feed.SetPermission(xypriv.Public, "read")
+
+	var failures = 1
+	var user = flakyUser{failures: &failures}
+
+	// Decisions failing open are not cached.
+	var d = engine.Check(user).Perform("read").Decide(feed)
+	fmt.Println(d.Allowed, d.FailedOpen)
+	d = engine.Check(user).Perform("read").Decide(feed)
+	fmt.Println(d.Allowed, d.FailedOpen)
+
+	// Output:
+	// true true
+	// true false
+}
+
+// flakyFeed implements FallibleStaticResource interface. Its permission store
+// is always unavailable.
+type flakyFeed struct{}
+
+// Context returns nil.
+func (flakyFeed) Context() any {
+	return nil
+}
+
+// Owner returns nil.
+func (flakyFeed) Owner() xypriv.Subject {
+	return nil
+}
+
+// Permission panics because the engine always prefers TryPermission.
+func (flakyFeed) Permission(action ...string) xypriv.AccessLevel {
+	panic("unreachable")
+}
+
+// TryPermission always returns an error.
+func (flakyFeed) TryPermission(action ...string) (xypriv.AccessLevel, error) {
+	return 0, errors.New("store is unavailable")
+}
+
+func ExampleFailurePolicy() {
+	var engine = xypriv.NewEngine()
+	engine.SetFailurePolicy(xypriv.FailurePolicy{
+		Mode:    xypriv.FailOpen,
+		Retries: 1,
+		Backoff: time.Hour,
+	})
+
+	var failures = 0
+	var user = flakyUser{failures: &failures}
+
+	// The check fails open, the unresolvable access level becomes Public.
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	var d = engine.Check(user).Perform("read").DecideContext(ctx, flakyFeed{})
+	fmt.Println(d.Allowed, d.FailedOpen, errors.Is(d.Err, xypriv.CanceledError))
+
+	// Failing open never bypasses the delegatee.
+	engine.SetFailurePolicy(xypriv.FailurePolicy{Mode: xypriv.FailOpen})
+	var token = engine.NewToken()
+	token.AllowAction("read")
+	token.Revoke()
+	d = engine.Check(user).Delegate(token).Perform("read").Decide(flakyFeed{})
+	fmt.Println(d.Allowed, d.FailedOpen, errors.Is(d.Err, xypriv.PermissionError))
+
+	token = engine.NewToken()
+	token.BanAction("read")
+	d = engine.Check(user).Delegate(token).Perform("read").Decide(flakyFeed{})
+	fmt.Println(d.Allowed, d.FailedOpen, d.Err)
+
+	// Output:
+	// false false true
+	// false true true
+	// false true PermissionError: flakyUser do not have the permission to read flakyFeed
+}
+
+func ExampleCircuitBreaker() {
+	var breaker = xypriv.NewCircuitBreaker(1, 20*time.Millisecond)
+	var engine = xypriv.NewEngine()
+	engine.SetFailurePolicy(xypriv.FailurePolicy{Mode: xypriv.FailClosed, Breaker: breaker})
+	var table = engine.AbstractResource("account_table")
+	table.SetPermission(xypriv.HighSecret, "delete")
+
+	var failures = 1
+	var user = flakyUser{failures: &failures}
+
+	// The failure opens the breaker, the store isn't called while it is open.
+	fmt.Println(engine.Check(user).Perform("delete").On(table) != nil, breaker.Open())
+	failures = 1
+	fmt.Println(engine.Check(user).Perform("delete").On(table) != nil, failures)
+
+	// After the cooldown, the failed trial call opens the breaker again.
+	time.Sleep(40 * time.Millisecond)
+	fmt.Println(engine.Check(user).Perform("delete").On(table) != nil, failures, breaker.Allow())
+
+	// While the breaker is half-open, exactly one trial call is let through.
+	time.Sleep(40 * time.Millisecond)
+	fmt.Println(breaker.Allow(), breaker.Allow())
+	breaker.Failure()
+
+	// The successful trial call closes the breaker.
+	time.Sleep(40 * time.Millisecond)
+	fmt.Println(engine.Check(user).Perform("delete").On(table), breaker.Open())
+
+	// Output:
+	// true true
+	// true 1
+	// true 0 false
+	// true false
+	// <nil> false
+}
@@ -0,0 +1,376 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// File store operations.
+const (
+	opRelation = "relation"
+	opParent   = "parent"
+	opMapping  = "mapping"
+	opResource = "resource"
+	opToken    = "token"
+	opWrite    = "write"
+	opDelete   = "delete"
+	opSnapshot = "snapshot"
+)
+
+// fileRecord is a line of FileStore.
+type fileRecord struct {
+	Time      time.Time        `json:"time"`
+	Op        string           `json:"op"`
+	Context   string           `json:"context,omitempty"`
+	Relation  Relation         `json:"relation,omitempty"`
+	Privilege Privilege        `json:"privilege,omitempty"`
+	Parent    string           `json:"parent,omitempty"`
+	Mapping   *RelationMapping `json:"mapping,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Resource  *PolicyResource  `json:"resource,omitempty"`
+	Token     *TokenRecord     `json:"token,omitempty"`
+	Tuples    []Tuple          `json:"tuples,omitempty"`
+	Snapshot  *Snapshot        `json:"snapshot,omitempty"`
+}
+
+// FileStore is a Store appending every change to a JSON-lines file. The whole
+// file is replayed when it is opened, Compact rewrites it as a single
+// snapshot.
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	policy *Policy
+	tuples *MemoryStore
+	tokens map[string]TokenRecord
+}
+
+// OpenFileStore opens the file at path, it is created if it doesn't exist. A
+// torn last line, which is left by a crash during a write, is truncated.
+func OpenFileStore(path string) (*FileStore, error) {
+	var s = &FileStore{path: path}
+	s.reset(NewSnapshot())
+
+	var size, terminated, err = s.replay()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !terminated {
+		if _, err := file.Write([]byte{'\n'}); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	s.file = file
+	return s, nil
+}
+
+// Write implements RelationStore interface.
+func (s *FileStore) Write(tuples ...Tuple) error {
+	if err := validateTuples(tuples); err != nil {
+		return err
+	}
+	return s.append(fileRecord{Op: opWrite, Tuples: tuples})
+}
+
+// Delete implements RelationStore interface.
+func (s *FileStore) Delete(tuples ...Tuple) error {
+	return s.append(fileRecord{Op: opDelete, Tuples: tuples})
+}
+
+// Read implements RelationStore interface.
+func (s *FileStore) Read(filter Tuple) ([]Tuple, error) {
+	return s.tuples.Read(filter)
+}
+
+// SaveRelation implements Store interface.
+func (s *FileStore) SaveRelation(context string, relation Relation, privilege Privilege) error {
+	return s.append(fileRecord{
+		Op:        opRelation,
+		Context:   context,
+		Relation:  relation,
+		Privilege: privilege,
+	})
+}
+
+// SaveParent implements Store interface.
+func (s *FileStore) SaveParent(context, parent string) error {
+	return s.append(fileRecord{Op: opParent, Context: context, Parent: parent})
+}
+
+// SaveMapping implements Store interface.
+func (s *FileStore) SaveMapping(m RelationMapping) error {
+	return s.append(fileRecord{Op: opMapping, Mapping: &m})
+}
+
+// SaveResource implements Store interface.
+func (s *FileStore) SaveResource(name string, r PolicyResource) error {
+	return s.append(fileRecord{Op: opResource, Name: name, Resource: &r})
+}
+
+// SaveToken implements Store interface.
+func (s *FileStore) SaveToken(t TokenRecord) error {
+	return s.append(fileRecord{Op: opToken, Token: &t})
+}
+
+// Load implements Store interface.
+func (s *FileStore) Load() (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot(), nil
+}
+
+// Snapshot writes the content of store to w in JSON format.
+func (s *FileStore) Snapshot(w io.Writer) error {
+	s.mu.Lock()
+	var snapshot = s.snapshot()
+	s.mu.Unlock()
+
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// Compact replaces the file with a single snapshot record, so that it no
+// longer grows with overwritten changes. The file is replaced atomically.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+
+	var tmp = s.path + ".tmp"
+	var file, err = os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	var record = fileRecord{Time: time.Now(), Op: opSnapshot, Snapshot: s.snapshot()}
+	if err := json.NewEncoder(file).Encode(record); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	// The old file is kept open until it is replaced, so that the store still
+	// works if the replacement fails.
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	var old = s.file
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		s.file = nil
+	}
+	if cerr := old.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Close closes the file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	var err = s.file.Close()
+	s.file = nil
+	return err
+}
+
+// append writes the record to the file, then applies it.
+func (s *FileStore) append(record fileRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+
+	record.Time = time.Now()
+	var b, err = json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+
+	s.apply(record)
+	return nil
+}
+
+// replay applies all records of the file. It returns the size of the valid
+// records, and false if the last valid record isn't terminated by a newline.
+// An unterminated last line is torn if it isn't a valid record.
+func (s *FileStore) replay() (int64, bool, error) {
+	var file, err = os.Open(s.path)
+	if os.IsNotExist(err) {
+		return 0, true, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	defer file.Close()
+
+	var reader = bufio.NewReader(file)
+	var size int64
+	for line := 1; ; line++ {
+		var b, err = reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return 0, false, err
+		}
+		if len(b) == 0 {
+			return size, true, nil
+		}
+
+		var terminated = b[len(b)-1] == '\n'
+		var record fileRecord
+		if jsonErr := json.Unmarshal(b, &record); jsonErr != nil {
+			if !terminated {
+				return size, true, nil
+			}
+			return 0, false, ConfigurationError.Newf("invalid record at %s:%d: %v", s.path, line, jsonErr)
+		}
+
+		s.apply(record)
+		size += int64(len(b))
+		if !terminated {
+			return size, false, nil
+		}
+	}
+}
+
+// apply applies the record to the content of store, the lock must be held.
+func (s *FileStore) apply(record fileRecord) {
+	switch record.Op {
+	case opRelation:
+		if _, ok := s.policy.Relations[record.Context]; !ok {
+			s.policy.Relations[record.Context] = make(map[Relation]Privilege)
+		}
+		s.policy.Relations[record.Context][record.Relation] = record.Privilege
+	case opParent:
+		if record.Parent == "" {
+			delete(s.policy.Parents, record.Context)
+		} else {
+			s.policy.Parents[record.Context] = record.Parent
+		}
+	case opMapping:
+		s.policy.addMapping(*record.Mapping)
+	case opResource:
+		s.policy.Resources[record.Name] = *record.Resource
+	case opToken:
+		s.tokens[record.Token.ID] = *record.Token
+	case opWrite:
+		s.tuples.Write(record.Tuples...)
+	case opDelete:
+		s.tuples.Delete(record.Tuples...)
+	case opSnapshot:
+		s.reset(record.Snapshot)
+	}
+}
+
+// reset replaces the content of store with the snapshot.
+func (s *FileStore) reset(snapshot *Snapshot) {
+	s.policy = NewPolicy()
+	s.tuples = NewMemoryStore()
+	s.tokens = make(map[string]TokenRecord)
+
+	if snapshot.Policy != nil {
+		for cname, cmap := range snapshot.Policy.Relations {
+			s.policy.Relations[cname] = make(map[Relation]Privilege)
+			for relation, privilege := range cmap {
+				s.policy.Relations[cname][relation] = privilege
+			}
+		}
+		for cname, parent := range snapshot.Policy.Parents {
+			s.policy.Parents[cname] = parent
+		}
+		for _, m := range snapshot.Policy.Mappings {
+			s.policy.addMapping(m)
+		}
+		for name, r := range snapshot.Policy.Resources {
+			s.policy.Resources[name] = r
+		}
+	}
+	s.tuples.Write(snapshot.Tuples...)
+	for _, t := range snapshot.Tokens {
+		s.tokens[t.ID] = t
+	}
+}
+
+// snapshot returns a copy of the content of store, the lock must be held.
+func (s *FileStore) snapshot() *Snapshot {
+	var snapshot = NewSnapshot()
+	for cname, cmap := range s.policy.Relations {
+		snapshot.Policy.Relations[cname] = make(map[Relation]Privilege)
+		for relation, privilege := range cmap {
+			snapshot.Policy.Relations[cname][relation] = privilege
+		}
+	}
+	for cname, parent := range s.policy.Parents {
+		snapshot.Policy.Parents[cname] = parent
+	}
+	snapshot.Policy.Mappings = append(snapshot.Policy.Mappings, s.policy.Mappings...)
+	for name, r := range s.policy.Resources {
+		snapshot.Policy.Resources[name] = r
+	}
+
+	snapshot.Tuples = s.tuples.all()
+	for _, t := range s.tokens {
+		snapshot.Tokens = append(snapshot.Tokens, t)
+	}
+	sortTokens(snapshot.Tokens)
+	return snapshot
+}
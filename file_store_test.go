@@ -0,0 +1,171 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleFileStore() {
+	var dir, _ = os.MkdirTemp("", "xypriv")
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, "store.jsonl")
+
+	var store, _ = xypriv.OpenFileStore(path)
+	var engine = xypriv.NewEngine()
+	engine.SetStore(store)
+
+	engine.AddRelation(nil, "editor", xypriv.Moderator)
+	engine.SetContextParent("team", "org")
+	engine.AddRelationMapping(xypriv.RelationMapping{
+		From: "org", Relation: "orgAdmin", To: "team", Privilege: xypriv.LocalAdmin,
+	})
+	var article = engine.AbstractResource("article")
+	article.SetOwner(identifiedUser{id: "alice"})
+	article.SetPermission(xypriv.HighConfidential, "update")
+	article.SetPermission(xypriv.Public, "read")
+
+	var token = engine.NewToken()
+	token.AllowAction("read")
+	token.Revoke()
+	store.Write(xypriv.Tuple{Object: "alice", Relation: "editor", Subject: "bob"})
+	store.Close()
+
+	var data, _ = os.ReadFile(path)
+	fmt.Println(strings.Count(string(data), "\n"))
+
+	// Restart.
+	store, _ = xypriv.OpenFileStore(path)
+	store.Compact()
+	data, _ = os.ReadFile(path)
+	fmt.Println(strings.Count(string(data), "\n"))
+
+	engine = xypriv.NewEngine()
+	fmt.Println(engine.SetStore(store), engine.StoreError())
+
+	var policy = engine.Policy()
+	fmt.Println(policy.Parents, policy.Mappings)
+
+	var bob = xypriv.StoreSubject{ID: "bob", Store: store, Engine: engine}
+	var resource, _ = engine.FindAbstractResource("article")
+	fmt.Println(resource.Owner())
+	fmt.Println(engine.Check(bob).Perform("update").On(resource))
+
+	var loaded, _ = engine.FindToken(token.TokenID())
+	fmt.Println(engine.Check(bob).Delegate(loaded).Perform("read").Decide(resource).Allowed)
+	store.Close()
+
+	// Output:
+	// 10
+	// 1
+	// <nil> <nil>
+	// map[team:org] [relation orgadmin in context org maps to privilege 8 in context team]
+	// {alice}
+	// <nil>
+	// false
+}
+
+func ExampleOpenFileStore() {
+	var dir, _ = os.MkdirTemp("", "xypriv")
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, "store.jsonl")
+
+	var store, _ = xypriv.OpenFileStore(path)
+	store.SaveRelation("nil", "editor", xypriv.Moderator)
+	store.Close()
+
+	// A crash leaves a torn last line.
+	var file, _ = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	file.WriteString(`{"op":"relation","context":"nil","rel`)
+	file.Close()
+
+	store, err := xypriv.OpenFileStore(path)
+	fmt.Println(err)
+	store.SaveRelation("nil", "viewer", xypriv.LowFamiliar)
+	store.Close()
+
+	store, err = xypriv.OpenFileStore(path)
+	var snapshot, _ = store.Load()
+	fmt.Println(err, snapshot.Policy.Relations)
+	store.Close()
+
+	var data, _ = os.ReadFile(path)
+	fmt.Println(strings.Count(string(data), "\n"))
+
+	// Output:
+	// <nil>
+	// <nil> map[nil:map[editor:7 viewer:2]]
+	// 2
+}
+
+func ExampleFileStore_Compact() {
+	var dir, _ = os.MkdirTemp("", "xypriv")
+	defer os.RemoveAll(dir)
+	var path = filepath.Join(dir, "store.jsonl")
+
+	var store, _ = xypriv.OpenFileStore(path)
+	store.SaveRelation("nil", "editor", xypriv.Moderator)
+
+	// The file can't be replaced by the snapshot, the store keeps working.
+	os.Rename(path, path+".old")
+	os.MkdirAll(filepath.Join(path, "busy"), 0755)
+	fmt.Println(store.Compact() != nil)
+	fmt.Println(store.SaveRelation("nil", "viewer", xypriv.LowFamiliar))
+	store.Close()
+
+	os.RemoveAll(path)
+	os.Rename(path+".old", path)
+	store, _ = xypriv.OpenFileStore(path)
+	var snapshot, _ = store.Load()
+	fmt.Println(snapshot.Policy.Relations)
+	store.Close()
+
+	// Output:
+	// true
+	// <nil>
+	// map[nil:map[editor:7 viewer:2]]
+}
+
+func ExampleEngine_SetStore() {
+	var dir, _ = os.MkdirTemp("", "xypriv")
+	defer os.RemoveAll(dir)
+
+	var store, _ = xypriv.OpenFileStore(filepath.Join(dir, "store.jsonl"))
+	defer store.Close()
+	store.SaveRelation("nil", "editor", xypriv.Moderator)
+	store.SaveToken(xypriv.TokenRecord{ID: "first"})
+
+	// Nothing is loaded from an invalid store.
+	var engine = xypriv.NewEngine()
+	fmt.Println(engine.SetStore(store))
+	fmt.Println(engine.Policy().Relations)
+
+	// Output:
+	// ConfigurationError: invalid token id "first"
+	// map[]
+}
@@ -2,10 +2,12 @@ module github.com/xybor-x/xypriv
 
 go 1.18
 
-require github.com/xybor-x/xyerror v1.0.5
+require (
+	github.com/xybor-x/xyerror v1.0.5
+	golang.org/x/sync v0.1.0
+)
 
 require (
 	github.com/xybor-x/xycond v1.0.0 // indirect
 	github.com/xybor-x/xylock v0.0.1 // indirect
-	golang.org/x/sync v0.1.0 // indirect
 )
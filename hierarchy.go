@@ -0,0 +1,107 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+// maxContextDepth limits the length of context chains to detect cycles.
+const maxContextDepth = 64
+
+// ContextParent instances are contexts having a parent context. A relation
+// which isn't mapped in the context is looked up in its parent.
+type ContextParent interface {
+	// ParentContext returns the parent context, or nil if the context is a
+	// root.
+	ParentContext() any
+}
+
+// SetContextParent registers the parent of child context in the default
+// engine.
+func SetContextParent(child, parent any) {
+	defaultEngine.SetContextParent(child, parent)
+}
+
+// SetContextParent registers the parent of child context in the engine. The
+// contexts are matched by name, so it applies to all contexts having the same
+// name as child. ContextParent takes precedence over the registration.
+func (e *Engine) SetContextParent(child, parent any) {
+	var cname = e.name(child)
+	var pname = ""
+	if parent != nil {
+		pname = e.name(parent)
+	}
+
+	e.setContextParent(cname, parent)
+	e.persist(func(s Store) error { return s.SaveParent(cname, pname) })
+	e.publish(Change{Kind: ParentChanged, Context: cname, Parent: pname})
+}
+
+// setContextParent registers the parent of context named cname, a nil parent
+// removes it.
+func (e *Engine) setContextParent(cname string, parent any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if parent == nil {
+		delete(e.parents, cname)
+	} else {
+		e.parents[cname] = parent
+	}
+	e.policyChanged()
+}
+
+// parentContext returns the parent of context, or nil.
+func (e *Engine) parentContext(context any) any {
+	if p, ok := context.(ContextParent); ok {
+		return p.ParentContext()
+	}
+
+	var cname = e.name(context)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.parents[cname]
+}
+
+// contextChain returns the names of context and its ancestors, from the
+// nearest one. It panics if the chain is a cycle.
+func (e *Engine) contextChain(context any) []string {
+	var ancestors = e.ancestors(context)
+	var chain = make([]string, len(ancestors))
+	for i := range ancestors {
+		chain[i] = e.name(ancestors[i])
+	}
+	return chain
+}
+
+// ancestors returns context and its ancestors, from the nearest one. It panics
+// if the chain is a cycle.
+func (e *Engine) ancestors(context any) []any {
+	var chain = []any{context}
+	for c := e.parentContext(context); c != nil; c = e.parentContext(c) {
+		if len(chain) >= maxContextDepth {
+			panic(ConfigurationError.Newf(
+				"the ancestors of context %s are too deep or a cycle", e.name(context)))
+		}
+		chain = append(chain, c)
+	}
+	return chain
+}
@@ -0,0 +1,114 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// Org is the root context.
+type Org struct {
+	id string
+}
+
+// Team is a context whose parent is an Org.
+type Team struct {
+	id  string
+	org Org
+}
+
+// ParentContext implements ContextParent interface.
+func (t Team) ParentContext() any {
+	return t.org
+}
+
+// Project is a context whose parent is registered by SetContextParent.
+type Project struct {
+	id string
+}
+
+// hierarchyUser implements Subject interface.
+type hierarchyUser struct {
+	role string
+}
+
+// Relation returns the role of user in all contexts.
+func (u hierarchyUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return xypriv.Relation(u.role)
+}
+
+// hierarchyDoc implements StaticResource interface.
+type hierarchyDoc struct {
+	context any
+}
+
+// Context returns the context of document.
+func (d hierarchyDoc) Context() any {
+	return d.context
+}
+
+// Owner returns no owner.
+func (d hierarchyDoc) Owner() xypriv.Subject {
+	return nil
+}
+
+// Permission requires LocalAdmin to delete the document.
+func (d hierarchyDoc) Permission(action ...string) xypriv.AccessLevel {
+	return xypriv.LowSecret
+}
+
+func ExampleContextParent() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(Org{}, "orgAdmin", xypriv.Admin)
+	engine.AddRelation(Org{}, "orgMember", xypriv.LowFamiliar)
+	engine.AddRelation(Team{}, "teamLead", xypriv.LocalAdmin)
+	engine.SetContextParent(Project{}, Team{})
+
+	var org = Org{id: "acme"}
+	var team = Team{id: "backend", org: org}
+
+	// A sandbox of the team doesn't trust org admins.
+	engine.AddRelation("sandbox", "orgAdmin", xypriv.Anyone)
+	engine.SetContextParent("sandbox", team)
+
+	for _, ctx := range []any{team, Project{id: "api"}, "sandbox"} {
+		for _, role := range []string{"orgAdmin", "teamLead", "orgMember"} {
+			var d = engine.Check(hierarchyUser{role: role}).Perform("delete").
+				Decide(hierarchyDoc{context: ctx})
+			fmt.Println(ctx, role, d.Privilege, d.Allowed)
+		}
+	}
+
+	// Output:
+	// {backend {acme}} orgAdmin 9 true
+	// {backend {acme}} teamLead 8 true
+	// {backend {acme}} orgMember 2 false
+	// {api} orgAdmin 9 true
+	// {api} teamLead 8 true
+	// {api} orgMember 2 false
+	// sandbox orgAdmin 1 false
+	// sandbox teamLead 8 true
+	// sandbox orgMember 2 false
+}
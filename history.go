@@ -0,0 +1,248 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Limits of the engines rebuilt by At which are cached.
+const (
+	maxPastStates = 16
+	pastStateTTL  = time.Minute
+)
+
+// History instances return recorded changes, so that the state of an engine
+// at any point in time can be rebuilt. MemoryBroadcaster is a History.
+type History interface {
+	// Until returns all changes whose times are not after t, in order.
+	Until(t time.Time) ([]Change, error)
+}
+
+// Until implements History interface.
+func (b *MemoryBroadcaster) Until(t time.Time) ([]Change, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var changes []Change
+	for _, c := range b.changes {
+		if c.Time.After(t) {
+			break
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// SetHistory sets the history of the default engine.
+func SetHistory(h History) {
+	defaultEngine.SetHistory(h)
+}
+
+// SetHistory sets the history used to rebuild past states of the engine. It
+// should record all changes of the engine and its relation stores, for
+// example the MemoryBroadcaster passed to SetBroadcaster and BroadcastStore.
+func (e *Engine) SetHistory(h History) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.history = h
+	e.pasts.purge()
+}
+
+// At rebuilds the engine as of t by replaying its history. Relations,
+// abstract resources, tokens, tuples, context parents, relation mappings,
+// lattices, and MAC modes are rebuilt. Other settings, such as the namer, are
+// copied from the current engine. Relations of subjects other than
+// StoreSubjects are always the current ones.
+//
+// Rebuilt engines are cached by the revision of their latest change for
+// pastStateTTL, and are dropped when the settings of engine change.
+func (e *Engine) At(t time.Time) (*Engine, error) {
+	e.mu.RLock()
+	var history = e.history
+	e.mu.RUnlock()
+
+	if history == nil {
+		return nil, ConfigurationError.New("the engine has no history")
+	}
+
+	var changes, err = history.Until(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var revision uint64
+	if len(changes) > 0 {
+		revision = changes[len(changes)-1].Revision
+	}
+
+	var key = strconv.FormatUint(revision, 10)
+	if past, ok := e.pasts.get(key, 0); ok {
+		return past.(*Engine), nil
+	}
+
+	var past = e.rebuild(changes)
+	past.revision = revision
+	e.pasts.add(key, past, pastStateTTL, nil, revision)
+	return past, nil
+}
+
+// rebuild creates an engine having the current settings of engine, then
+// applies the changes to it.
+func (e *Engine) rebuild(changes []Change) *Engine {
+	var past = NewEngine()
+	var tuples = NewMemoryStore()
+
+	e.mu.RLock()
+	past.namer = e.namer
+	past.strategy = e.strategy
+	past.failure = e.failure
+	e.mu.RUnlock()
+
+	for _, c := range changes {
+		switch c.Kind {
+		case RelationAdded:
+			tuples.Write(c.Tuple)
+		case RelationRemoved:
+			tuples.Delete(c.Tuple)
+		case PrivilegeChanged:
+			past.addRelation(c.Context, c.Relation, c.Privilege)
+		case PermissionChanged:
+			var r = past.AbstractResource(c.Resource)
+			r.setPermission(c.AccessLevel, c.Action)
+		case ResourceChanged:
+			past.applyResource(c)
+		case TokenRevoked:
+			past.revokeToken(c.Token)
+		case TokenChanged:
+			past.loadToken(TokenRecord{ID: c.Token, Subject: c.Subject, Rules: c.Rules})
+		case ParentChanged:
+			past.setContextParent(c.Context, policyContext(c.Parent))
+		case MappingAdded:
+			past.addRelationMapping(*c.Mapping)
+		case LatticeChanged:
+			past.setLattice(c.Context, c.Lattice)
+		case MACChanged:
+			past.setMAC(c.MAC)
+		case ActionModeChanged:
+			past.setActionMode(c.Mode, c.Action)
+		}
+	}
+
+	past.tuples = tuples
+	return past
+}
+
+// AsOf evaluates the check against the state of engine as of t, which is
+// rebuilt from the engine history. Abstract resources and tokens are replaced
+// by the ones of the past having the same names and IDs, a token which didn't
+// exist at t allows nothing. Historical decisions are neither cached nor
+// audited.
+func (c *Checker) AsOf(t time.Time) *Checker {
+	c.asOf = t
+	return c
+}
+
+// decideAsOf evaluates the check against the past engine.
+func (c *Checker) decideAsOf(ctx context.Context, resource Resource) Decision {
+	var past, err = c.engine.At(c.asOf)
+	if err != nil {
+		return Decision{Subject: c.subject, Resource: resource, Action: c.action, Err: err}
+	}
+
+	var checker = *c
+	checker.engine = past
+	checker.delegatee = past.shadowToken(c.delegatee)
+	return past.safeDecide(ctx, &checker, past.shadowResource(resource))
+}
+
+// shadowToken returns the token of the engine having the same ID if d is a
+// token of another engine. A token unknown to the engine allows nothing.
+func (e *Engine) shadowToken(d Delegatee) Delegatee {
+	var t *LeastPrivilegeToken
+	switch v := d.(type) {
+	case *LeastPrivilegeToken:
+		t = v
+	case LeastPrivilegeToken:
+		t = &v
+	default:
+		return d
+	}
+
+	if t.getEngine() == e {
+		return d
+	}
+
+	if stored, ok := e.FindToken(t.TokenID()); ok {
+		return stored
+	}
+	return &LeastPrivilegeToken{id: t.id, rules: map[string]bool{}, engine: e}
+}
+
+// publishResource publishes the context, the owner, and the labels of abstract
+// resource.
+func (e *Engine) publishResource(name string) {
+	e.mu.RLock()
+	var r, ok = e.resources[name]
+	e.mu.RUnlock()
+
+	if ok {
+		e.publish(Change{
+			Kind:           ResourceChanged,
+			Resource:       name,
+			Context:        e.name(r.context),
+			Owner:          e.objectID(r.owner),
+			Classification: labelOf(r.classification),
+			Integrity:      labelOf(r.integrity),
+		})
+	}
+}
+
+// publishToken publishes the bound subject and the rules of token.
+func (e *Engine) publishToken(t *LeastPrivilegeToken) {
+	var rules = make(map[string]bool, len(t.rules))
+	for k, v := range t.rules {
+		rules[k] = v
+	}
+
+	e.publish(Change{
+		Kind:    TokenChanged,
+		Token:   t.TokenID(),
+		Subject: t.subject,
+		Rules:   rules,
+	})
+}
+
+// at returns a copy of subject reading tuples from the store of a past engine.
+func (s StoreSubject) at(past *Engine, tuples RelationStore) StoreSubject {
+	if g, ok := s.Store.(*Graph); ok {
+		return StoreSubject{
+			ID:     s.ID,
+			Store:  &Graph{Store: tuples, MaxDepth: g.MaxDepth, rules: g.rules},
+			Engine: past,
+		}
+	}
+	return StoreSubject{ID: s.ID, Store: tuples, Engine: past}
+}
@@ -0,0 +1,144 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleChecker_AsOf() {
+	var history = xypriv.NewMemoryBroadcaster()
+	var engine = xypriv.NewEngine()
+	engine.SetBroadcaster(history)
+	engine.SetHistory(history)
+
+	var store = xypriv.BroadcastStore{Store: xypriv.NewMemoryStore(), Broadcaster: history}
+	var alice = xypriv.StoreSubject{ID: "alice", Store: store, Engine: engine}
+	var bob = xypriv.StoreSubject{ID: "bob", Store: store, Engine: engine}
+
+	engine.AddRelation(nil, "friend", xypriv.LowFamiliar)
+	var album = engine.AbstractResource("album")
+	album.SetOwner(alice)
+	album.SetPermission(xypriv.LowPrivate, "read")
+	store.Write(engine.NewTuple(nil, alice, "friend", "bob"))
+
+	time.Sleep(time.Millisecond)
+	var tuesday = time.Now()
+	time.Sleep(time.Millisecond)
+
+	store.Delete(engine.NewTuple(nil, alice, "friend", "bob"))
+	album.SetPermission(xypriv.Public, "comment")
+
+	fmt.Println(engine.Check(bob).Perform("read").On(album))
+	fmt.Println(engine.Check(bob).Perform("read").AsOf(tuesday).On(album))
+	fmt.Println(engine.Check(bob).Perform("comment").On(album))
+	fmt.Println(engine.Check(bob).Perform("comment").AsOf(tuesday).On(album))
+	fmt.Println(xypriv.NewEngine().Check(bob).AsOf(tuesday).On(album))
+
+	// Output:
+	// PermissionError: bob do not have the permission to read album
+	// <nil>
+	// <nil>
+	// PermissionError: bob do not have the permission to comment album
+	// ConfigurationError: the engine has no history
+}
+
+func ExampleChecker_AsOf_tokens() {
+	var history = xypriv.NewMemoryBroadcaster()
+	var engine = xypriv.NewEngine()
+	engine.SetBroadcaster(history)
+	engine.SetHistory(history)
+
+	engine.AddRelation(nil, "editor", xypriv.Moderator)
+	var article = engine.AbstractResource("article")
+	article.SetPermission(xypriv.HighConfidential, "update")
+	article.SetPermission(xypriv.HighConfidential, "delete")
+
+	time.Sleep(time.Millisecond)
+	var monday = time.Now()
+	time.Sleep(time.Millisecond)
+
+	var token = engine.NewToken()
+	token.AllowAction("update")
+
+	time.Sleep(time.Millisecond)
+	var tuesday = time.Now()
+	time.Sleep(time.Millisecond)
+
+	token.AllowAction("delete")
+
+	var check = func(action string, t time.Time) error {
+		return engine.Check(watchEditor{}).Delegate(token).Perform(action).AsOf(t).On(article)
+	}
+	fmt.Println(check("update", monday) != nil)
+	fmt.Println(check("update", tuesday))
+	fmt.Println(check("delete", tuesday) != nil)
+	fmt.Println(check("delete", time.Now()))
+
+	var past, _ = engine.At(tuesday)
+	var again, _ = engine.At(tuesday)
+	fmt.Println(past == again)
+
+	// Output:
+	// true
+	// <nil>
+	// true
+	// <nil>
+	// true
+}
+
+func ExampleChecker_AsOf_mappings() {
+	var history = xypriv.NewMemoryBroadcaster()
+	var engine = xypriv.NewEngine()
+	engine.SetBroadcaster(history)
+	engine.SetHistory(history)
+
+	engine.AddRelation("org", "orgAdmin", xypriv.Admin)
+	engine.AddRelation("team", "teamMember", xypriv.LowFamiliar)
+	var doc = engine.AbstractResource("doc")
+	doc.SetContext("team")
+	doc.SetPermission(xypriv.LowPrivate, "delete")
+
+	time.Sleep(time.Millisecond)
+	var monday = time.Now()
+	time.Sleep(time.Millisecond)
+
+	engine.SetContextParent("team", "org")
+	engine.AddRelationMapping(xypriv.RelationMapping{
+		From:      "org",
+		Relation:  "orgAdmin",
+		To:        "team",
+		Privilege: xypriv.LocalAdmin,
+	})
+
+	var admin = watchMember{relations: map[string]xypriv.Relation{"org": "orgAdmin"}}
+	fmt.Println(engine.Check(admin).Perform("delete").On(doc))
+	fmt.Println(engine.Check(admin).Perform("delete").AsOf(monday).On(doc))
+
+	// Output:
+	// <nil>
+	// PermissionError: watchMember do not have the permission to delete doc
+}
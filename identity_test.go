@@ -0,0 +1,116 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// identifiedUser implements Subject and Identified interfaces. Its Relation
+// method doesn't need to handle the self relation.
+type identifiedUser struct {
+	id string
+}
+
+// Relation returns "anyone", the self relation is decided by the engine.
+func (u identifiedUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return "anyone"
+}
+
+// SubjectID implements Identified interface.
+func (u identifiedUser) SubjectID() string {
+	return u.id
+}
+
+// identifiedGroup implements Subject and Identified interfaces. Its IDs may
+// collide with the IDs of users.
+type identifiedGroup struct {
+	id string
+}
+
+// Relation returns "anyone".
+func (g identifiedGroup) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return "anyone"
+}
+
+// SubjectID implements Identified interface.
+func (g identifiedGroup) SubjectID() string {
+	return g.id
+}
+
+// identifiedAvatar implements StaticResource interface.
+type identifiedAvatar struct {
+	user identifiedUser
+}
+
+// Context returns the normal context.
+func (a identifiedAvatar) Context() any {
+	return nil
+}
+
+// Owner returns the user of avatar.
+func (a identifiedAvatar) Owner() xypriv.Subject {
+	return a.user
+}
+
+// Permission returns the access level of avatar.
+func (a identifiedAvatar) Permission(action ...string) xypriv.AccessLevel {
+	return xypriv.TopSecret
+}
+
+func ExampleIdentified() {
+	var engine = xypriv.NewEngine()
+	var alice = identifiedUser{id: "alice"}
+	var bob = identifiedUser{id: "bob"}
+	var avatar = identifiedAvatar{user: alice}
+
+	fmt.Println(engine.Check(alice).Perform("update").Decide(avatar).Relation)
+	fmt.Println(engine.Check(bob).Perform("update").On(avatar))
+
+	var token = xypriv.NewToken()
+	token.AllowAction("update")
+	token.Bind("alice")
+
+	fmt.Println(engine.Check(alice).Delegate(token).Perform("update").On(avatar))
+	fmt.Println(engine.Check(bob).Delegate(token).Perform("update").On(avatar))
+
+	// A group whose ID collides with alice is not alice.
+	var group = identifiedGroup{id: "alice"}
+	fmt.Println(engine.Check(group).Perform("update").Decide(avatar).Relation)
+
+	// An owner loaded from a store matches alice by the ID only.
+	var profile = engine.AbstractResource("profile")
+	profile.SetPermission(xypriv.TopSecret, "update")
+	profile.SetOwner(xypriv.SubjectRef{ID: "alice"})
+	fmt.Println(engine.Check(alice).Perform("update").Decide(profile).Relation)
+
+	// Output:
+	// self
+	// PermissionError: bob do not have the permission to update identifiedAvatar
+	// <nil>
+	// PermissionError: the token of bob is bound to another subject
+	// anyone
+	// self
+}
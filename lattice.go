@@ -0,0 +1,171 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Lattice instances decide whether a privilege is enough for an access level.
+type Lattice interface {
+	// Dominates returns true if privilege p dominates access level l.
+	Dominates(p Privilege, l AccessLevel) bool
+}
+
+// IntegerLattice is the default Lattice, a privilege dominates an access level
+// if it is not less than the access level.
+type IntegerLattice struct{}
+
+// Dominates implements Lattice interface.
+func (IntegerLattice) Dominates(p Privilege, l AccessLevel) bool {
+	return int(p) >= int(l)
+}
+
+// SetLattice sets the lattice of context in the default engine.
+func SetLattice(context any, l Lattice) {
+	defaultEngine.SetLattice(context, l)
+}
+
+// SetLattice sets the lattice deciding checks on resources in the context of
+// engine. Contexts without any lattice use the one of their nearest ancestor,
+// or IntegerLattice. Pass a nil lattice to remove it. Policy diffs skip the
+// resources in contexts having a lattice.
+func (e *Engine) SetLattice(context any, l Lattice) {
+	var cname = e.name(context)
+	e.setLattice(cname, l)
+	e.publish(Change{Kind: LatticeChanged, Context: cname, Lattice: l})
+}
+
+// setLattice sets the lattice of context named cname, a nil lattice removes
+// it.
+func (e *Engine) setLattice(cname string, l Lattice) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if l == nil {
+		delete(e.lattices, cname)
+	} else {
+		e.lattices[cname] = l
+	}
+	e.policyChanged()
+}
+
+// lattice returns the lattice of context.
+func (e *Engine) lattice(context any) Lattice {
+	var chain = e.contextChain(context)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, cname := range chain {
+		if l, ok := e.lattices[cname]; ok {
+			return l
+		}
+	}
+	return IntegerLattice{}
+}
+
+// Label is a security label of multi-level security, it is a level with a set
+// of categories.
+type Label struct {
+	Level      int      `json:"level"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// labelOf returns a pointer to the label, or nil if it is the lowest one.
+func labelOf(l Label) *Label {
+	if l.Level == 0 && len(l.Categories) == 0 {
+		return nil
+	}
+	return &l
+}
+
+// Dominates returns true if the level of label is not less than the one of
+// other, and its categories include all categories of other.
+func (label Label) Dominates(other Label) bool {
+	if label.Level < other.Level {
+		return false
+	}
+
+	var categories = make(map[string]struct{}, len(label.Categories))
+	for _, c := range label.Categories {
+		categories[c] = struct{}{}
+	}
+	for _, c := range other.Categories {
+		if _, ok := categories[c]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the label as "level{category,...}".
+func (label Label) String() string {
+	var categories = append([]string(nil), label.Categories...)
+	sort.Strings(categories)
+	return fmt.Sprintf("%d{%s}", label.Level, strings.Join(categories, ","))
+}
+
+// MLSLattice is a multi-level security Lattice. Privileges and access levels
+// are mapped to Labels, so that Secret{finance} doesn't dominate Secret{hr}.
+// Unmapped privileges and access levels never dominate nor are dominated.
+type MLSLattice struct {
+	mu         sync.RWMutex
+	privileges map[Privilege]Label
+	levels     map[AccessLevel]Label
+}
+
+// NewMLSLattice creates an empty MLSLattice.
+func NewMLSLattice() *MLSLattice {
+	return &MLSLattice{
+		privileges: make(map[Privilege]Label),
+		levels:     make(map[AccessLevel]Label),
+	}
+}
+
+// SetPrivilege maps the privilege to label.
+func (m *MLSLattice) SetPrivilege(p Privilege, label Label) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.privileges[p] = label
+}
+
+// SetAccessLevel maps the access level to label.
+func (m *MLSLattice) SetAccessLevel(l AccessLevel, label Label) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.levels[l] = label
+}
+
+// Dominates implements Lattice interface.
+func (m *MLSLattice) Dominates(p Privilege, l AccessLevel) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var pl, ok1 = m.privileges[p]
+	var ll, ok2 = m.levels[l]
+	return ok1 && ok2 && pl.Dominates(ll)
+}
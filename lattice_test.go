@@ -0,0 +1,102 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// Compliance is a context whose checks use a MLS lattice.
+type Compliance struct{}
+
+// Clearance privileges and classification access levels of the compliance
+// context.
+const (
+	SecretFinance xypriv.Privilege = iota + 100
+	SecretHR
+	TopSecretAll
+
+	ReportFinance xypriv.AccessLevel = iota + 100
+	ReportHR
+)
+
+// complianceDoc implements StaticResource interface.
+type complianceDoc struct {
+	level xypriv.AccessLevel
+}
+
+// Context returns the compliance context.
+func (d complianceDoc) Context() any {
+	return Compliance{}
+}
+
+// Owner returns no owner.
+func (d complianceDoc) Owner() xypriv.Subject {
+	return nil
+}
+
+// Permission returns the classification of document.
+func (d complianceDoc) Permission(action ...string) xypriv.AccessLevel {
+	return d.level
+}
+
+// complianceUser implements Subject interface.
+type complianceUser struct {
+	role xypriv.Relation
+}
+
+// Relation returns the role of user.
+func (u complianceUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return u.role
+}
+
+func ExampleMLSLattice() {
+	var lattice = xypriv.NewMLSLattice()
+	lattice.SetPrivilege(SecretFinance, xypriv.Label{Level: 2, Categories: []string{"finance"}})
+	lattice.SetPrivilege(SecretHR, xypriv.Label{Level: 2, Categories: []string{"hr"}})
+	lattice.SetPrivilege(TopSecretAll, xypriv.Label{Level: 3, Categories: []string{"finance", "hr"}})
+	lattice.SetAccessLevel(ReportFinance, xypriv.Label{Level: 2, Categories: []string{"finance"}})
+	lattice.SetAccessLevel(ReportHR, xypriv.Label{Level: 1, Categories: []string{"hr"}})
+
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(Compliance{}, "analyst", SecretFinance)
+	engine.AddRelation(Compliance{}, "recruiter", SecretHR)
+	engine.AddRelation(Compliance{}, "auditor", TopSecretAll)
+	engine.SetLattice(Compliance{}, lattice)
+
+	var finance = complianceDoc{level: ReportFinance}
+	var hr = complianceDoc{level: ReportHR}
+	for _, role := range []xypriv.Relation{"analyst", "recruiter", "auditor"} {
+		var user = complianceUser{role: role}
+		var d1 = engine.Check(user).Perform("read").Decide(finance)
+		var d2 = engine.Check(user).Perform("read").Decide(hr)
+		fmt.Println(role, d1.Allowed, d2.Allowed)
+	}
+
+	// Output:
+	// analyst true false
+	// recruiter false true
+	// auditor true true
+}
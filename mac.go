@@ -0,0 +1,168 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import "strings"
+
+// MACMode is a set of mandatory access control models, which are enforced in
+// addition to the discretionary privilege check.
+type MACMode int
+
+// MAC modes, they can be combined with "|".
+const (
+	// BellLaPadula enforces confidentiality, subjects can't read up nor write
+	// down.
+	BellLaPadula MACMode = 1 << iota
+
+	// Biba enforces integrity, subjects can't read down nor write up.
+	Biba
+)
+
+// AccessMode tags an action as reading or writing.
+type AccessMode int
+
+// Access modes. Untagged actions are considered as ReadWrite.
+const (
+	Read AccessMode = 1 << iota
+	Write
+
+	ReadWrite = Read | Write
+)
+
+// Cleared instances are Subjects having a clearance in BellLaPadula mode.
+type Cleared interface {
+	// Clearance returns the security label of subject.
+	Clearance() Label
+}
+
+// Classified instances are Resources having a classification in BellLaPadula
+// mode.
+type Classified interface {
+	// Classification returns the security label of resource.
+	Classification() Label
+}
+
+// IntegrityLabeled instances are Subjects or Resources having an integrity
+// level in Biba mode.
+type IntegrityLabeled interface {
+	// Integrity returns the integrity label of object.
+	Integrity() Label
+}
+
+// SetMAC sets the MAC modes of the default engine.
+func SetMAC(mode MACMode) {
+	defaultEngine.SetMAC(mode)
+}
+
+// SetMAC sets the MAC modes enforced by the engine after the privilege check.
+// Subjects and resources without labels have the lowest label. Pass zero to
+// disable MAC.
+func (e *Engine) SetMAC(mode MACMode) {
+	e.setMAC(mode)
+	e.publish(Change{Kind: MACChanged, MAC: mode})
+}
+
+// setMAC sets the MAC modes of engine.
+func (e *Engine) setMAC(mode MACMode) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mac = mode
+	e.policyChanged()
+}
+
+// SetActionMode tags the action as reading or writing in the default engine.
+func SetActionMode(mode AccessMode, action ...string) {
+	defaultEngine.SetActionMode(mode, action...)
+}
+
+// SetActionMode tags the action as reading or writing in MAC modes.
+func (e *Engine) SetActionMode(mode AccessMode, action ...string) {
+	var actions = strings.Join(action, "_")
+	e.setActionMode(mode, actions)
+	e.publish(Change{Kind: ActionModeChanged, Action: actions, Mode: mode})
+}
+
+// setActionMode tags the joined actions as reading or writing.
+func (e *Engine) setActionMode(mode AccessMode, actions string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.modes[actions] = mode
+	e.policyChanged()
+}
+
+// checkMAC returns a MACError if the check breaks a MAC rule of engine.
+func (e *Engine) checkMAC(c *Checker, resource Resource) error {
+	var actions = strings.Join(c.action, "_")
+
+	e.mu.RLock()
+	var mac = e.mac
+	var mode, ok = e.modes[actions]
+	e.mu.RUnlock()
+
+	if mac == 0 {
+		return nil
+	}
+	if !ok {
+		mode = ReadWrite
+	}
+
+	if mac&BellLaPadula != 0 {
+		var clearance, classification Label
+		if s, ok := c.subject.(Cleared); ok {
+			clearance = s.Clearance()
+		}
+		if r, ok := resource.(Classified); ok {
+			classification = r.Classification()
+		}
+
+		if mode&Read != 0 && !clearance.Dominates(classification) {
+			return MACError.Newf("%s can't read up %s classified as %s",
+				e.idOf(c.subject), e.resourceName(resource), classification)
+		}
+		if mode&Write != 0 && !classification.Dominates(clearance) {
+			return MACError.Newf("%s can't write down %s classified as %s",
+				e.idOf(c.subject), e.resourceName(resource), classification)
+		}
+	}
+
+	if mac&Biba != 0 {
+		var subject, object Label
+		if s, ok := c.subject.(IntegrityLabeled); ok {
+			subject = s.Integrity()
+		}
+		if r, ok := resource.(IntegrityLabeled); ok {
+			object = r.Integrity()
+		}
+
+		if mode&Read != 0 && !object.Dominates(subject) {
+			return MACError.Newf("%s can't read down %s of integrity %s",
+				e.idOf(c.subject), e.resourceName(resource), object)
+		}
+		if mode&Write != 0 && !subject.Dominates(object) {
+			return MACError.Newf("%s can't write up %s of integrity %s",
+				e.idOf(c.subject), e.resourceName(resource), object)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// pipelineJob implements Subject and Cleared interfaces.
+type pipelineJob struct {
+	name      string
+	clearance int
+}
+
+// Relation returns "operator".
+func (j pipelineJob) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return "operator"
+}
+
+// SubjectID implements Identified interface.
+func (j pipelineJob) SubjectID() string {
+	return j.name
+}
+
+// Clearance implements Cleared interface.
+func (j pipelineJob) Clearance() xypriv.Label {
+	return xypriv.Label{Level: j.clearance}
+}
+
+// dataset implements StaticResource and Classified interfaces.
+type dataset struct {
+	classification int
+}
+
+// Context returns the normal context.
+func (d dataset) Context() any {
+	return nil
+}
+
+// Owner returns no owner.
+func (d dataset) Owner() xypriv.Subject {
+	return nil
+}
+
+// Permission returns Public for all actions.
+func (d dataset) Permission(action ...string) xypriv.AccessLevel {
+	return xypriv.Public
+}
+
+// Classification implements Classified interface.
+func (d dataset) Classification() xypriv.Label {
+	return xypriv.Label{Level: d.classification}
+}
+
+func ExampleBellLaPadula() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(nil, "operator", xypriv.LowFamiliar)
+	engine.SetMAC(xypriv.BellLaPadula)
+	engine.SetActionMode(xypriv.Read, "read")
+	engine.SetActionMode(xypriv.Write, "write")
+
+	var job = pipelineJob{name: "etl", clearance: 2}
+	var public, secret = dataset{classification: 0}, dataset{classification: 3}
+
+	fmt.Println(engine.Check(job).Perform("read").On(public))
+	fmt.Println(engine.Check(job).Perform("read").On(secret))
+	fmt.Println(engine.Check(job).Perform("write").On(secret))
+
+	var err = engine.Check(job).Perform("write").On(public)
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, xypriv.MACError), errors.Is(err, xypriv.PermissionError))
+
+	// Output:
+	// <nil>
+	// MACError: etl can't read up dataset classified as 3{}
+	// <nil>
+	// MACError: etl can't write down dataset classified as 0{}
+	// true true
+}
+
+// sensor implements Subject and IntegrityLabeled interfaces.
+type sensor struct {
+	integrity int
+}
+
+// Relation returns "operator".
+func (s sensor) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return "operator"
+}
+
+// Integrity implements IntegrityLabeled interface.
+func (s sensor) Integrity() xypriv.Label {
+	return xypriv.Label{Level: s.integrity}
+}
+
+// ledger implements StaticResource and IntegrityLabeled interfaces.
+type ledger struct {
+	dataset
+}
+
+// Integrity implements IntegrityLabeled interface.
+func (l ledger) Integrity() xypriv.Label {
+	return xypriv.Label{Level: 2}
+}
+
+func ExampleBiba() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(nil, "operator", xypriv.LowFamiliar)
+	engine.SetMAC(xypriv.Biba)
+	engine.SetActionMode(xypriv.Write, "append")
+
+	fmt.Println(engine.Check(sensor{integrity: 1}).Perform("append").On(ledger{}))
+	fmt.Println(engine.Check(sensor{integrity: 3}).Perform("append").On(ledger{}))
+	fmt.Println(engine.Check(sensor{integrity: 2}).Perform("reconcile").On(ledger{}))
+
+	// Output:
+	// MACError: sensor can't write up ledger of integrity 2{}
+	// <nil>
+	// <nil>
+}
+
+func ExampleAbstractResourceDetails_SetClassification() {
+	var dir, _ = os.MkdirTemp("", "xypriv")
+	defer os.RemoveAll(dir)
+	var store, _ = xypriv.OpenFileStore(filepath.Join(dir, "store.jsonl"))
+	defer store.Close()
+
+	var engine = xypriv.NewEngine()
+	engine.SetStore(store)
+	engine.AddRelation(nil, "operator", xypriv.LowFamiliar)
+	engine.SetMAC(xypriv.BellLaPadula | xypriv.Biba)
+	engine.SetActionMode(xypriv.Read, "read")
+
+	var payroll = engine.AbstractResource("payroll")
+	payroll.SetPermission(xypriv.Public, "read")
+	payroll.SetClassification(xypriv.Label{Level: 3, Categories: []string{"hr"}})
+	payroll.SetIntegrity(xypriv.Label{Level: 1})
+
+	var job = pipelineJob{name: "etl", clearance: 2}
+	fmt.Println(engine.Check(job).Perform("read").On(payroll))
+
+	var policy = engine.Policy()
+	fmt.Println(*policy.Resources["payroll"].Classification, *policy.Resources["payroll"].Integrity)
+
+	// The labels are restored from the store.
+	var restored = xypriv.NewEngine()
+	restored.SetStore(store)
+	restored.SetMAC(xypriv.BellLaPadula | xypriv.Biba)
+	restored.SetActionMode(xypriv.Read, "read")
+	var resource, _ = restored.FindAbstractResource("payroll")
+	fmt.Println(restored.Check(job).Perform("read").On(resource))
+
+	// Output:
+	// MACError: etl can't read up payroll classified as 3{hr}
+	// 3{hr} 1{}
+	// MACError: etl can't read up payroll classified as 3{hr}
+}
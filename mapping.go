@@ -0,0 +1,152 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RelationMapping is a rule translating a relation in an ancestor context to a
+// privilege in a descendant context. For example, the relation "orgAdmin" in
+// context Org implies LocalAdmin in any Team context whose ancestor is that
+// Org.
+type RelationMapping struct {
+	// From is the ancestor context, matched by name.
+	From any `json:"from"`
+
+	// Relation is the relation of subject in the From context.
+	Relation Relation `json:"relation"`
+
+	// To is the descendant context, matched by name.
+	To any `json:"to"`
+
+	// Privilege is the privilege of subject in the To context if it has the
+	// relation in the From context.
+	Privilege Privilege `json:"privilege"`
+}
+
+// String returns the description of mapping.
+func (m RelationMapping) String() string {
+	return fmt.Sprintf("relation %s in context %v maps to privilege %d in context %v",
+		m.Relation, m.From, m.Privilege, m.To)
+}
+
+// mappingRule is a RelationMapping whose contexts are resolved to names.
+type mappingRule struct {
+	RelationMapping
+	from string
+	to   string
+}
+
+// AddRelationMapping adds a cross-context mapping rule to the default engine.
+func AddRelationMapping(m RelationMapping) {
+	defaultEngine.AddRelationMapping(m)
+}
+
+// AddRelationMapping adds a cross-context mapping rule to the engine. The
+// engine applies the rule when the privilege of subject in the resource
+// context is weaker than the mapped one, and the subject has the relation in
+// an ancestor context. A BadRelation privilege is never raised, so a subject
+// banned in the resource context stays banned. The applied rules are explained
+// in Decision.
+func (e *Engine) AddRelationMapping(m RelationMapping) {
+	var rule = e.addRelationMapping(m)
+	e.persist(func(s Store) error { return s.SaveMapping(rule.RelationMapping) })
+	e.publish(Change{Kind: MappingAdded, Mapping: &rule.RelationMapping})
+}
+
+// addRelationMapping resolves the contexts of mapping to names, then adds the
+// rule to the engine.
+func (e *Engine) addRelationMapping(m RelationMapping) mappingRule {
+	m.Relation = Relation(strings.ToLower(string(m.Relation)))
+	var rule = mappingRule{
+		RelationMapping: m,
+		from:            e.name(m.From),
+		to:              e.name(m.To),
+	}
+
+	// Keep the names only, they are used in the explanation.
+	rule.RelationMapping.From = rule.from
+	rule.RelationMapping.To = rule.to
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mappings = append(e.mappings, rule)
+	e.policyChanged()
+	return rule
+}
+
+// applyMappings raises the privilege of decision by the mapping rules whose
+// target is the resource context. A BadRelation privilege is never raised.
+func (e *Engine) applyMappings(reqCtx context.Context, d *Decision) error {
+	e.mu.RLock()
+	var rules = e.mappings
+	e.mu.RUnlock()
+
+	if len(rules) == 0 || d.Privilege == BadRelation {
+		return nil
+	}
+
+	var cname = e.name(d.Context)
+	var ancestors []any
+	for _, rule := range rules {
+		if rule.to != cname || rule.Privilege <= d.Privilege {
+			continue
+		}
+
+		if ancestors == nil {
+			ancestors = e.ancestors(d.Context)
+		}
+
+		for _, a := range ancestors[1:] {
+			if e.name(a) != rule.from {
+				continue
+			}
+
+			var relations, err = e.relationsOf(reqCtx, a, d.Subject, d.Owner)
+			if err != nil {
+				return err
+			}
+
+			if hasRelation(relations, rule.Relation) {
+				d.Privilege = rule.Privilege
+				d.Explanation = append(d.Explanation, rule.RelationMapping.String())
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasRelation returns true if relations contain the lower-case relation.
+func hasRelation(relations []Relation, relation Relation) bool {
+	for _, r := range relations {
+		if Relation(strings.ToLower(string(r))) == relation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// mappingUser implements Subject interface.
+type mappingUser struct {
+	orgRole  string
+	teamRole string
+}
+
+// Relation returns the role of user in the org or team context.
+func (u mappingUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	switch ctx.(type) {
+	case Org:
+		return xypriv.Relation(u.orgRole)
+	case Team:
+		return xypriv.Relation(u.teamRole)
+	}
+	return "anyone"
+}
+
+func ExampleRelationMapping() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(Org{}, "orgAdmin", xypriv.Admin)
+	engine.AddRelation(Org{}, "orgMember", xypriv.LowFamiliar)
+	engine.AddRelation(Team{}, "teamMember", xypriv.LowFamiliar)
+	engine.AddRelation(Team{}, "teamBanned", xypriv.BadRelation)
+	engine.AddRelationMapping(xypriv.RelationMapping{
+		From:      Org{},
+		Relation:  "orgAdmin",
+		To:        Team{},
+		Privilege: xypriv.LocalAdmin,
+	})
+
+	var team = Team{id: "backend", org: Org{id: "acme"}}
+	var doc = hierarchyDoc{context: team}
+
+	var admin = mappingUser{orgRole: "orgAdmin", teamRole: "teamMember"}
+	var d = engine.Check(admin).Perform("delete").Decide(doc)
+	fmt.Println(d.Relation, d.Privilege, d.Allowed)
+	fmt.Println(d.Explanation)
+
+	var member = mappingUser{orgRole: "orgMember", teamRole: "teamMember"}
+	d = engine.Check(member).Perform("delete").Decide(doc)
+	fmt.Println(d.Relation, d.Privilege, d.Allowed)
+	fmt.Println(d.Explanation)
+
+	// A subject banned in the team is never raised by the mapping.
+	var banned = mappingUser{orgRole: "orgAdmin", teamRole: "teamBanned"}
+	d = engine.Check(banned).Perform("read").Decide(doc)
+	fmt.Println(d.Relation, d.Privilege, d.Allowed)
+	fmt.Println(d.Explanation)
+
+	// Output:
+	// teamMember 8 true
+	// [relation orgadmin in context Org maps to privilege 8 in context Team]
+	// teamMember 2 false
+	// []
+	// teamBanned 0 false
+	// []
+}
@@ -0,0 +1,176 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"encoding/json"
+	"expvar"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics instances receive the instrumentation of engine.
+type Metrics interface {
+	// ObserveDecision is called after every check.
+	ObserveDecision(resource, action string, allowed bool)
+
+	// ObserveRelation is called with the latency of resolving the relation
+	// and its privilege.
+	ObserveRelation(d time.Duration)
+
+	// ObservePermission is called with the latency of looking up the access
+	// level of resource.
+	ObservePermission(d time.Duration)
+
+	// ObservePanic is called when a check panics.
+	ObservePanic()
+}
+
+// SetMetrics sets the metrics of the default engine. Pass nil to stop the
+// instrumentation.
+func SetMetrics(m Metrics) {
+	defaultEngine.SetMetrics(m)
+}
+
+// SetMetrics sets the metrics of the engine. Pass nil to stop the
+// instrumentation.
+func (e *Engine) SetMetrics(m Metrics) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.metrics = m
+}
+
+// getMetrics returns the metrics of engine, it may be nil.
+func (e *Engine) getMetrics() Metrics {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.metrics
+}
+
+// latencyBuckets are the upper bounds of histogram buckets.
+var latencyBuckets = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// histogram is a latency histogram which can be published by expvar.
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     uint64
+}
+
+// newHistogram creates a histogram with latencyBuckets and an overflow bucket.
+func newHistogram() *histogram {
+	return &histogram{buckets: make([]uint64, len(latencyBuckets)+1)}
+}
+
+// observe adds d to histogram.
+func (h *histogram) observe(d time.Duration) {
+	var i = 0
+	for i < len(latencyBuckets) && d > latencyBuckets[i] {
+		i++
+	}
+	atomic.AddUint64(&h.buckets[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddUint64(&h.sum, uint64(d))
+}
+
+// String implements expvar.Var interface.
+func (h *histogram) String() string {
+	var buckets = make(map[string]uint64, len(h.buckets))
+	for i := range latencyBuckets {
+		buckets["le_"+latencyBuckets[i].String()] = atomic.LoadUint64(&h.buckets[i])
+	}
+	buckets["le_inf"] = atomic.LoadUint64(&h.buckets[len(latencyBuckets)])
+
+	var b, _ = json.Marshal(map[string]any{
+		"buckets": buckets,
+		"count":   atomic.LoadUint64(&h.count),
+		"sum_ns":  atomic.LoadUint64(&h.sum),
+	})
+	return string(b)
+}
+
+// ExpvarMetrics implements Metrics by publishing counters and histograms via
+// expvar.
+type ExpvarMetrics struct {
+	allowed           *expvar.Map
+	denied            *expvar.Map
+	panics            *expvar.Int
+	relationLatency   *histogram
+	permissionLatency *histogram
+}
+
+// NewExpvarMetrics creates an ExpvarMetrics and publishes it via expvar with
+// the name. Like expvar.Publish, it panics if the name is already registered.
+//
+// Decision counters are keyed by "resource.action".
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	var m = &ExpvarMetrics{
+		allowed:           new(expvar.Map).Init(),
+		denied:            new(expvar.Map).Init(),
+		panics:            new(expvar.Int),
+		relationLatency:   newHistogram(),
+		permissionLatency: newHistogram(),
+	}
+
+	var root = expvar.NewMap(name)
+	root.Set("allowed", m.allowed)
+	root.Set("denied", m.denied)
+	root.Set("panics", m.panics)
+	root.Set("relation_latency", m.relationLatency)
+	root.Set("permission_latency", m.permissionLatency)
+
+	return m
+}
+
+// ObserveDecision implements Metrics interface.
+func (m *ExpvarMetrics) ObserveDecision(resource, action string, allowed bool) {
+	var key = strings.Join([]string{resource, action}, ".")
+	if allowed {
+		m.allowed.Add(key, 1)
+	} else {
+		m.denied.Add(key, 1)
+	}
+}
+
+// ObserveRelation implements Metrics interface.
+func (m *ExpvarMetrics) ObserveRelation(d time.Duration) {
+	m.relationLatency.observe(d)
+}
+
+// ObservePermission implements Metrics interface.
+func (m *ExpvarMetrics) ObservePermission(d time.Duration) {
+	m.permissionLatency.observe(d)
+}
+
+// ObservePanic implements Metrics interface.
+func (m *ExpvarMetrics) ObservePanic() {
+	m.panics.Add(1)
+}
@@ -0,0 +1,101 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleNewExpvarMetrics() {
+	var engine = xypriv.NewEngine()
+	engine.SetMetrics(xypriv.NewExpvarMetrics("xypriv_example"))
+
+	var table = engine.AbstractResource("account_table")
+	table.SetPermission(xypriv.HighSecret, "create", "admin")
+
+	engine.Check(roleUser{role: "admin"}).Perform("create", "admin").On(table)
+	engine.Check(roleUser{role: "moderator"}).Perform("create", "admin").On(table)
+	engine.Check(roleUser{role: "anyone"}).Perform("create", "admin").On(table)
+
+	func() {
+		defer func() { recover() }()
+		engine.Check(roleUser{role: "unknown"}).Perform("create", "admin").On(table)
+	}()
+
+	var vars = expvar.Get("xypriv_example").(*expvar.Map)
+	fmt.Println("allowed:", vars.Get("allowed"))
+	fmt.Println("denied:", vars.Get("denied"))
+	fmt.Println("panics:", vars.Get("panics"))
+
+	// Output:
+	// allowed: {"account_table.create_admin": 1}
+	// denied: {"account_table.create_admin": 2}
+	// panics: 1
+}
+
+func ExampleExpvarMetrics_ObserveRelation() {
+	var metrics = xypriv.NewExpvarMetrics("xypriv_histogram_example")
+
+	// A latency on a bucket bound is counted in that bucket.
+	for _, d := range []time.Duration{
+		5 * time.Microsecond,
+		10 * time.Microsecond,
+		50 * time.Microsecond,
+		2 * time.Millisecond,
+		5 * time.Second,
+	} {
+		metrics.ObserveRelation(d)
+	}
+
+	var vars = expvar.Get("xypriv_histogram_example").(*expvar.Map)
+	var histogram struct {
+		Buckets map[string]uint64 `json:"buckets"`
+		Count   uint64            `json:"count"`
+		Sum     uint64            `json:"sum_ns"`
+	}
+	if err := json.Unmarshal([]byte(vars.Get("relation_latency").String()), &histogram); err != nil {
+		panic(err)
+	}
+
+	for _, bound := range []string{"10µs", "100µs", "1ms", "10ms", "100ms", "1s", "inf"} {
+		fmt.Printf("le_%s: %d\n", bound, histogram.Buckets["le_"+bound])
+	}
+	fmt.Println("count:", histogram.Count, "sum_ns:", histogram.Sum)
+	fmt.Println("permission_latency:", vars.Get("permission_latency"))
+
+	// Output:
+	// le_10µs: 2
+	// le_100µs: 1
+	// le_1ms: 0
+	// le_10ms: 1
+	// le_100ms: 0
+	// le_1s: 0
+	// le_inf: 1
+	// count: 5 sum_ns: 5002065000
+	// permission_latency: {"buckets":{"le_100ms":0,"le_100µs":0,"le_10ms":0,"le_10µs":0,"le_1ms":0,"le_1s":0,"le_inf":0},"count":0,"sum_ns":0}
+}
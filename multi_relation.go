@@ -0,0 +1,109 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import "fmt"
+
+// MultiRelationSubject instances are Subjects which may have many relations
+// over another Subject at the same time, such as both "friend" and
+// "groupAdmin".
+type MultiRelationSubject interface {
+	Subject
+
+	// Relations returns all relations of the current Subject over passed
+	// Subject. It is preferred over Relation.
+	Relations(ctx any, s Subject) []Relation
+}
+
+// FallibleMultiRelationSubject instances are MultiRelationSubjects whose
+// relation lookup may fail, for example when they are backed by a
+// RelationStore.
+type FallibleMultiRelationSubject interface {
+	MultiRelationSubject
+
+	// TryRelations works like Relations, but returns an error if the relations
+	// can't be found. It is preferred over Relations.
+	TryRelations(ctx any, s Subject) ([]Relation, error)
+}
+
+// RelationStrategy decides the effective relation among many relations of a
+// MultiRelationSubject.
+type RelationStrategy int
+
+// Relation strategies.
+const (
+	// MaxPrivilege chooses the relation having the highest privilege.
+	MaxPrivilege RelationStrategy = iota
+
+	// BadRelationWins chooses a relation having the BadRelation privilege if
+	// there is any, so that bans can't be overridden by other relations.
+	// Otherwise, it works like MaxPrivilege.
+	BadRelationWins
+)
+
+// SetRelationStrategy sets the relation strategy of the default engine.
+func SetRelationStrategy(s RelationStrategy) {
+	defaultEngine.SetRelationStrategy(s)
+}
+
+// SetRelationStrategy sets the relation strategy of the engine. The default
+// strategy is MaxPrivilege.
+func (e *Engine) SetRelationStrategy(s RelationStrategy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.strategy = s
+	e.policyChanged()
+}
+
+// combine sets the effective relation and its privilege of the decision from
+// all relations. A subject without any relation is considered as "anyone".
+func (e *Engine) combine(d *Decision) {
+	if len(d.Relations) == 0 {
+		d.Relations = []Relation{"anyone"}
+	}
+
+	e.mu.RLock()
+	var strategy = e.strategy
+	e.mu.RUnlock()
+
+	var effective = -1
+	var privileges = make([]Privilege, len(d.Relations))
+	for i, relation := range d.Relations {
+		privileges[i] = e.getPrivilege(d.Context, relation)
+		if strategy == BadRelationWins && privileges[i] == BadRelation {
+			effective = i
+			break
+		}
+		if effective == -1 || privileges[i] > privileges[effective] {
+			effective = i
+		}
+	}
+
+	d.Relation = d.Relations[effective]
+	d.Privilege = privileges[effective]
+
+	if len(d.Relations) > 1 {
+		d.Explanation = append(d.Explanation, fmt.Sprintf(
+			"relation %s is effective among %v", d.Relation, d.Relations))
+	}
+}
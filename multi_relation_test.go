@@ -0,0 +1,74 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// multiUser implements MultiRelationSubject interface.
+type multiUser struct {
+	roles []xypriv.Relation
+}
+
+// Relation is never called because Relations is preferred.
+func (u multiUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	panic("unreachable")
+}
+
+// Relations returns all roles of user in the group.
+func (u multiUser) Relations(ctx any, subject xypriv.Subject) []xypriv.Relation {
+	return u.roles
+}
+
+func ExampleMultiRelationSubject() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(Team{}, "friend", xypriv.LowFamiliar)
+	engine.AddRelation(Team{}, "groupAdmin", xypriv.LocalAdmin)
+	engine.AddRelation(Team{}, "banned", xypriv.BadRelation)
+
+	var doc = hierarchyDoc{context: Team{id: "backend"}}
+
+	var user = multiUser{roles: []xypriv.Relation{"friend", "groupAdmin"}}
+	var d = engine.Check(user).Perform("read").Decide(doc)
+	fmt.Println(d.Relation, d.Privilege, d.Allowed)
+	fmt.Println(d.Explanation)
+
+	var banned = multiUser{roles: []xypriv.Relation{"groupAdmin", "banned"}}
+	fmt.Println(engine.Check(banned).Perform("read").Decide(doc).Relation)
+
+	engine.SetRelationStrategy(xypriv.BadRelationWins)
+	fmt.Println(engine.Check(banned).Perform("read").Decide(doc).Relation)
+
+	var nobody = multiUser{}
+	fmt.Println(engine.Check(nobody).Perform("read").Decide(doc).Relation)
+
+	// Output:
+	// groupAdmin 8 true
+	// [relation groupAdmin is effective among [friend groupAdmin]]
+	// groupAdmin
+	// banned
+	// anyone
+}
@@ -0,0 +1,198 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Namer instances give names to contexts, resources, and token scopes. Two
+// objects having the same name are considered as the same context or scope.
+type Namer interface {
+	// Name returns the name of v. The v is never nil.
+	Name(v any) string
+}
+
+// NamerFunc is a function implementing Namer interface.
+type NamerFunc func(v any) string
+
+// Name implements Namer interface.
+func (f NamerFunc) Name(v any) string {
+	return f(v)
+}
+
+// Built-in namers.
+var (
+	// SimpleNamer names an object by its String method, the string itself, or
+	// its bare type name. It only supports strings, structs, and pointers of
+	// struct. This is the default namer.
+	SimpleNamer Namer = NamerFunc(simpleName)
+
+	// QualifiedNamer names an object by its String method, the string itself,
+	// or its type name qualified by the package path, so that types having
+	// the same name in different packages don't collide. It supports all
+	// kinds of objects.
+	QualifiedNamer Namer = NamerFunc(qualifiedName)
+)
+
+// contextNames stores the names registered by RegisterContextName.
+var contextNames = struct {
+	sync.RWMutex
+	m map[reflect.Type]string
+}{m: make(map[reflect.Type]string)}
+
+// RegisterContextName makes all objects having the same type as v, or pointers
+// of that type, named by the name, regardless of the namer of engine. It helps
+// to keep contexts and token scopes stable across refactors. Strings can't be
+// registered, because they are always named by themselves.
+func RegisterContextName(v any, name string) {
+	var vtype = reflect.TypeOf(v)
+	for vtype != nil && vtype.Kind() == reflect.Pointer {
+		vtype = vtype.Elem()
+	}
+
+	if vtype == nil || vtype.Kind() == reflect.String {
+		panic(ConfigurationError.New("expected a non-string object to register its name"))
+	}
+
+	contextNames.Lock()
+	defer contextNames.Unlock()
+
+	if old, ok := contextNames.m[vtype]; ok && old != name {
+		panic(ConfigurationError.Newf("type %s is already named %s", vtype, old))
+	}
+	contextNames.m[vtype] = name
+}
+
+// SetNamer sets the namer of the default engine.
+func SetNamer(n Namer) {
+	defaultEngine.SetNamer(n)
+}
+
+// SetNamer sets the namer of the engine. It should be called before adding
+// relations and creating tokens, because the names are resolved at that time.
+func (e *Engine) SetNamer(n Namer) {
+	if n == nil {
+		n = SimpleNamer
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.namer = n
+}
+
+// ContextName returns the name of context as the engine resolves it in
+// policies and token rules. The normal context is named "nil". It helps remote
+// callers to refer to contexts by name.
+func (e *Engine) ContextName(ctx any) string {
+	return e.name(ctx)
+}
+
+// name returns the name of object. Dots are replaced by underscores, because
+// they separate the elements of token rules.
+func (e *Engine) name(a any) string {
+	if a == nil {
+		return "nil"
+	}
+
+	var atype = reflect.TypeOf(a)
+	for atype.Kind() == reflect.Pointer {
+		atype = atype.Elem()
+	}
+
+	contextNames.RLock()
+	var name, ok = contextNames.m[atype]
+	contextNames.RUnlock()
+
+	if !ok {
+		e.mu.RLock()
+		var namer = e.namer
+		e.mu.RUnlock()
+
+		name = namer.Name(a)
+	}
+
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// idOf returns the ID of Identified objects, or the name of others.
+func (e *Engine) idOf(a any) string {
+	if i, ok := a.(Identified); ok {
+		return i.SubjectID()
+	}
+	return e.name(a)
+}
+
+// resourceName returns the name of resource, abstract resources are named by
+// their registered name.
+func (e *Engine) resourceName(resource Resource) string {
+	if r, ok := resource.(AbstractResourceDetails); ok && r.name != "" {
+		return r.name
+	}
+	return e.name(resource)
+}
+
+// simpleName implements SimpleNamer.
+func simpleName(a any) string {
+	if s, ok := a.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	var atype = reflect.TypeOf(a)
+	switch atype.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(a).String()
+	case reflect.Struct:
+		return atype.Name()
+	case reflect.Pointer:
+		return atype.Elem().Name()
+	default:
+		panic(XyprivError.New("expected a string, struct, or pointer of struct to get its name"))
+	}
+}
+
+// qualifiedName implements QualifiedNamer.
+func qualifiedName(a any) string {
+	if s, ok := a.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	var atype = reflect.TypeOf(a)
+	if atype.Kind() == reflect.String && atype.PkgPath() == "" {
+		return reflect.ValueOf(a).String()
+	}
+
+	for atype.Kind() == reflect.Pointer {
+		atype = atype.Elem()
+	}
+
+	if atype.Name() == "" || atype.PkgPath() == "" {
+		// Unnamed types such as map[string]int, and predeclared types.
+		return atype.String()
+	}
+
+	return atype.PkgPath() + ":" + atype.Name()
+}
@@ -0,0 +1,68 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// namingGroup is a context.
+type namingGroup struct{}
+
+// namingTeam is a context with a registered name.
+type namingTeam struct{}
+
+// namingLevel is a context of a non-struct kind.
+type namingLevel int
+
+func ExampleQualifiedNamer() {
+	xypriv.RegisterContextName(namingTeam{}, "team")
+
+	var engine = xypriv.NewEngine()
+	engine.SetNamer(xypriv.QualifiedNamer)
+	engine.AddRelation(namingGroup{}, "member", xypriv.LowFamiliar)
+	engine.AddRelation(&namingTeam{}, "member", xypriv.LowFamiliar)
+	engine.AddRelation(namingLevel(1), "member", xypriv.LowFamiliar)
+	engine.AddRelation(map[string]int{}, "member", xypriv.LowFamiliar)
+	engine.AddRelation("nil", "member", xypriv.LowFamiliar)
+
+	var names []string
+	for cname := range engine.Policy().Relations {
+		names = append(names, cname)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Println(name)
+	}
+
+	// Output:
+	// github_com/xybor-x/xypriv_test:namingGroup
+	// github_com/xybor-x/xypriv_test:namingLevel
+	// map[string]int
+	// nil
+	// team
+}
@@ -0,0 +1,190 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package pdp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// DefaultTimeout bounds the requests of a Client created without an
+// http.Client, and the requests of its Delegatee checked without a deadline.
+const DefaultTimeout = 5 * time.Second
+
+// Client sends requests to a Server.
+type Client struct {
+	url  string
+	http *http.Client
+}
+
+// NewClient creates a Client of the Server at url. An http.Client with
+// DefaultTimeout is used if hc is nil.
+func NewClient(url string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{url: strings.TrimRight(url, "/"), http: hc}
+}
+
+// Check returns the decision of request.
+func (c *Client) Check(ctx context.Context, req CheckRequest) (Decision, error) {
+	var d Decision
+	err := c.post(ctx, CheckPath, req, &d)
+	return d, err
+}
+
+// Batch returns the decisions of all requests in the same order.
+func (c *Client) Batch(ctx context.Context, reqs ...CheckRequest) ([]Decision, error) {
+	var resp BatchResponse
+	if err := c.post(ctx, BatchPath, BatchRequest{Requests: reqs}, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Decisions) != len(reqs) {
+		return nil, xypriv.XyprivError.Newf(
+			"expected %d decisions, but got %d", len(reqs), len(resp.Decisions))
+	}
+	return resp.Decisions, nil
+}
+
+// Enforce works like xypriv Checker.On, but the check is performed remotely.
+// It returns the error of server rebuilt with its class, so a child class of
+// PermissionError is still a PermissionError. A denial without an error is a
+// PermissionError.
+func (c *Client) Enforce(ctx context.Context, req CheckRequest) error {
+	var d, err = c.Check(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if !d.Allowed {
+		if d.Error != "" {
+			return xypriv.NewClassError(d.ErrorClass, d.Error)
+		}
+		return xypriv.PermissionError.Newf("%s do not have the permission to %s %s",
+			req.Subject.ID, strings.Join(req.Action, "_"), req.Resource.Name)
+	}
+
+	return nil
+}
+
+// Delegatee returns a Delegatee which asks the Server whether the relation of
+// subject allows the action on resource. The resource is identified by its
+// name, and the request is denied if the Server can't be reached. The request
+// uses the context.Context of check, it is bounded by DefaultTimeout if the
+// context has no deadline.
+func (c *Client) Delegatee(subject SubjectDescriptor) xypriv.Delegatee {
+	return remoteDelegatee{client: c, subject: subject}
+}
+
+// post sends the JSON request to path and decodes the JSON response to resp.
+func (c *Client) post(ctx context.Context, path string, req, resp any) error {
+	var body, err = json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		var msg, _ = io.ReadAll(io.LimitReader(httpResp.Body, 1024))
+		return xypriv.XyprivError.Newf("pdp responded %d: %s",
+			httpResp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return json.NewDecoder(httpResp.Body).Decode(resp)
+}
+
+// remoteDelegatee is a Delegatee asking a Server.
+type remoteDelegatee struct {
+	client  *Client
+	subject SubjectDescriptor
+}
+
+// Delegate implements Delegatee interface.
+func (d remoteDelegatee) Delegate(relation xypriv.Relation, resource xypriv.Resource, action ...string) bool {
+	return d.DelegateWithContext(context.Background(), relation, resource, action...)
+}
+
+// DelegateWithContext implements DelegateeWithContext interface.
+func (d remoteDelegatee) DelegateWithContext(
+	ctx context.Context, relation xypriv.Relation, resource xypriv.Resource, action ...string,
+) bool {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	var req = CheckRequest{
+		Subject:  d.subject,
+		Relation: relation,
+		Resource: ResourceDescriptor{Name: nameOf(resource)},
+		Action:   action,
+	}
+
+	if c := resource.Context(); c != nil {
+		req.Resource.Context = nameOf(c)
+	}
+
+	var decision, err = d.client.Check(ctx, req)
+	return err == nil && decision.Allowed
+}
+
+// nameOf returns the name of an abstract resource, a fmt.Stringer, or the type
+// name of v.
+func nameOf(v any) string {
+	switch t := v.(type) {
+	case xypriv.AbstractResourceDetails:
+		return t.Name()
+	case string:
+		return t
+	case fmt.Stringer:
+		return t.String()
+	}
+
+	var vtype = reflect.TypeOf(v)
+	if vtype.Kind() == reflect.Pointer {
+		vtype = vtype.Elem()
+	}
+	return vtype.Name()
+}
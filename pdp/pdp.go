@@ -0,0 +1,234 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package pdp provides a Policy Decision Point, which exposes the decisions of
+// an xypriv engine through HTTP/JSON, and its Go client.
+package pdp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// SubjectDescriptor describes the subject of a check.
+type SubjectDescriptor struct {
+	ID string `json:"id"`
+}
+
+// RelationFact is a known relation of the subject over an owner in a context.
+type RelationFact struct {
+	// Context is the context name given by Engine.ContextName of server, an
+	// empty context is the normal context.
+	Context  string          `json:"context,omitempty"`
+	Owner    string          `json:"owner"`
+	Relation xypriv.Relation `json:"relation"`
+}
+
+// ResourceDescriptor describes the resource of a check.
+type ResourceDescriptor struct {
+	// Name is the name of abstract resource in the engine of server.
+	Name string `json:"name"`
+
+	// Context overrides the context of abstract resource if it is not empty.
+	Context string `json:"context,omitempty"`
+
+	// Owner is the ID of resource owner. The resource has no owner if it is
+	// empty.
+	Owner string `json:"owner,omitempty"`
+}
+
+// CheckRequest is a request to check if the subject can perform the action on
+// resource.
+type CheckRequest struct {
+	Subject SubjectDescriptor `json:"subject"`
+
+	// Relations are the relation facts of subject. The relation of subject
+	// is "self" if the subject is the owner, the matched fact, or "anyone".
+	Relations []RelationFact `json:"relations,omitempty"`
+
+	// Relation is the relation of subject over owner in the resource
+	// context. If it is not empty, Relations are ignored.
+	Relation xypriv.Relation `json:"relation,omitempty"`
+
+	Resource ResourceDescriptor `json:"resource"`
+	Action   []string           `json:"action"`
+}
+
+// Decision is the response of a CheckRequest.
+type Decision struct {
+	Allowed     bool               `json:"allowed"`
+	Relation    xypriv.Relation    `json:"relation,omitempty"`
+	Privilege   xypriv.Privilege   `json:"privilege"`
+	AccessLevel xypriv.AccessLevel `json:"access_level"`
+	Error       string             `json:"error,omitempty"`
+
+	// ErrorClass is the xypriv error class of Error, such as PermissionError
+	// or ResourceError. The client rebuilds the error of this class.
+	ErrorClass string `json:"error_class,omitempty"`
+}
+
+// BatchRequest contains many CheckRequests.
+type BatchRequest struct {
+	Requests []CheckRequest `json:"requests"`
+}
+
+// BatchResponse contains the decisions of a BatchRequest in the same order.
+type BatchResponse struct {
+	Decisions []Decision `json:"decisions"`
+}
+
+// remoteSubject is a Subject whose relations are given by a CheckRequest.
+// Facts are matched against the context names of engine.
+type remoteSubject struct {
+	engine    *xypriv.Engine
+	id        string
+	facts     []RelationFact
+	relation  xypriv.Relation
+	isRelated bool
+}
+
+// Relation implements Subject interface.
+func (s remoteSubject) Relation(ctx any, owner xypriv.Subject) xypriv.Relation {
+	if s.isRelated {
+		return s.relation
+	}
+
+	var ownerID = ""
+	if o, ok := owner.(xypriv.Identified); ok {
+		ownerID = o.SubjectID()
+	}
+
+	var cname = s.engine.ContextName(ctx)
+	for _, f := range s.facts {
+		var fname = f.Context
+		if fname == "" {
+			fname = s.engine.ContextName(nil)
+		}
+
+		if fname == cname && f.Owner == ownerID {
+			return f.Relation
+		}
+	}
+
+	return "anyone"
+}
+
+// SubjectID implements Identified interface.
+func (s remoteSubject) SubjectID() string {
+	return s.id
+}
+
+// remoteResource is an abstract resource whose context and owner are given by
+// a ResourceDescriptor.
+type remoteResource struct {
+	details xypriv.AbstractResourceDetails
+	context any
+	owner   xypriv.Subject
+}
+
+// Context implements Resource interface.
+func (r remoteResource) Context() any {
+	return r.context
+}
+
+// Owner implements Resource interface.
+func (r remoteResource) Owner() xypriv.Subject {
+	return r.owner
+}
+
+// Permission implements StaticResource interface.
+func (r remoteResource) Permission(action ...string) xypriv.AccessLevel {
+	return r.details.Permission(action...)
+}
+
+// Classification implements Classified interface.
+func (r remoteResource) Classification() xypriv.Label {
+	return r.details.Classification()
+}
+
+// Integrity implements IntegrityLabeled interface.
+func (r remoteResource) Integrity() xypriv.Label {
+	return r.details.Integrity()
+}
+
+// String returns the name of abstract resource.
+func (r remoteResource) String() string {
+	return r.details.Name()
+}
+
+// evaluate checks the request against the engine, the check is canceled when
+// ctx is done.
+func evaluate(ctx context.Context, engine *xypriv.Engine, req CheckRequest) (decision Decision) {
+	defer func() {
+		if r := recover(); r != nil {
+			decision = Decision{Error: fmt.Sprint(r), ErrorClass: xypriv.ErrorClass(asError(r))}
+		}
+	}()
+
+	var details, ok = engine.FindAbstractResource(req.Resource.Name)
+	if !ok {
+		var err = xypriv.ResourceError.Newf("unknown resource %s", req.Resource.Name)
+		return Decision{Error: err.Error(), ErrorClass: xypriv.ErrorClass(err)}
+	}
+
+	var resource = remoteResource{
+		details: details,
+		context: details.Context(),
+		owner:   details.Owner(),
+	}
+	if req.Resource.Context != "" {
+		resource.context = req.Resource.Context
+	}
+	if req.Resource.Owner != "" {
+		resource.owner = xypriv.SubjectRef{ID: req.Resource.Owner}
+	}
+
+	var subject = remoteSubject{
+		engine:    engine,
+		id:        req.Subject.ID,
+		facts:     req.Relations,
+		relation:  req.Relation,
+		isRelated: req.Relation != "",
+	}
+
+	var d = engine.Check(subject).Perform(req.Action...).DecideContext(ctx, resource)
+	decision = Decision{
+		Allowed:     d.Allowed,
+		Relation:    d.Relation,
+		Privilege:   d.Privilege,
+		AccessLevel: d.AccessLevel,
+	}
+	if d.Err != nil {
+		decision.Error = d.Err.Error()
+		decision.ErrorClass = xypriv.ErrorClass(d.Err)
+	}
+
+	return decision
+}
+
+// asError returns the recovered value r as an error, or nil.
+func asError(r any) error {
+	var err, _ = r.(error)
+	return err
+}
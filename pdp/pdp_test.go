@@ -0,0 +1,234 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package pdp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/xybor-x/xypriv"
+	"github.com/xybor-x/xypriv/pdp"
+)
+
+// Group is a context which isn't a string.
+type Group struct{}
+
+// User implements Subject interface.
+type User struct {
+	role string
+}
+
+// Relation returns the role of user as its relation.
+func (u User) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return xypriv.Relation(u.role)
+}
+
+func Example() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation("group", "sameGroup", xypriv.LowFamiliar)
+	var post = engine.AbstractResource("group_post")
+	post.SetContext("group")
+	post.SetPermission(xypriv.LowPrivate, "read")
+	post.SetPermission(xypriv.HighSecret, "update")
+
+	var server = httptest.NewServer(pdp.NewServer(engine))
+	defer server.Close()
+
+	var client = pdp.NewClient(server.URL, server.Client())
+	var ctx = context.Background()
+
+	var read = pdp.CheckRequest{
+		Subject: pdp.SubjectDescriptor{ID: "alice"},
+		Relations: []pdp.RelationFact{
+			{Context: "group", Owner: "bob", Relation: "sameGroup"},
+		},
+		Resource: pdp.ResourceDescriptor{Name: "group_post", Owner: "bob"},
+		Action:   []string{"read"},
+	}
+
+	var update = read
+	update.Action = []string{"update"}
+
+	var own = update
+	own.Subject.ID = "bob"
+
+	decisions, err := client.Batch(ctx, read, update, own)
+	if err != nil {
+		panic(err)
+	}
+	for _, d := range decisions {
+		fmt.Println(d.Relation, d.Allowed)
+	}
+
+	if err := client.Enforce(ctx, update); err != nil {
+		fmt.Println(err)
+	}
+
+	// The error class is sent along with the error.
+	var unknown = read
+	unknown.Resource.Name = "unknown_post"
+	err = client.Enforce(ctx, unknown)
+	fmt.Println(err, errors.Is(err, xypriv.ResourceError))
+
+	// The remote delegatee lets a local check ask the PDP.
+	var local = xypriv.NewEngine()
+	local.AddRelation("group", "sameGroup", xypriv.LowFamiliar)
+	var localPost = local.AbstractResource("group_post")
+	localPost.SetContext("group")
+	localPost.SetPermission(xypriv.LowPrivate, "read")
+	localPost.SetPermission(xypriv.LowPrivate, "share")
+
+	var delegatee = client.Delegatee(pdp.SubjectDescriptor{ID: "alice"})
+	var alice = User{role: "sameGroup"}
+	fmt.Println(local.Check(alice).Delegate(delegatee).Perform("read").On(localPost) == nil)
+	fmt.Println(local.Check(alice).Delegate(delegatee).Perform("share").On(localPost) == nil)
+
+	// Output:
+	// sameGroup true
+	// sameGroup false
+	// self true
+	// PermissionError: alice do not have the permission to update group_post
+	// ResourceError: unknown resource unknown_post true
+	// true
+	// false
+}
+
+func ExampleClient_Delegatee() {
+	// The server never answers before the check is canceled.
+	var release = make(chan struct{})
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	var local = xypriv.NewEngine()
+	local.AddRelation(nil, "member", xypriv.LowFamiliar)
+	var doc = local.AbstractResource("doc")
+	doc.SetPermission(xypriv.Public, "read")
+
+	var client = pdp.NewClient(server.URL, nil)
+	var delegatee = client.Delegatee(pdp.SubjectDescriptor{ID: "alice"})
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var d = local.Check(User{role: "member"}).Delegate(delegatee).Perform("read").DecideContext(ctx, doc)
+	fmt.Println(d.Allowed, errors.Is(d.Err, xypriv.CanceledError))
+
+	// Output:
+	// false true
+}
+
+func ExampleServer_maxRequestSize() {
+	var server = httptest.NewServer(pdp.NewServer(xypriv.NewEngine()))
+	defer server.Close()
+
+	// Requests larger than MaxRequestSize are rejected.
+	var body = `{"requests": [` + strings.Repeat(`{},`, pdp.MaxRequestSize/3) + `{}]}`
+	var resp, err = http.Post(server.URL+pdp.BatchPath, "application/json", strings.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+	resp.Body.Close()
+	fmt.Println(resp.StatusCode)
+
+	// Output:
+	// 400
+}
+
+func ExampleServer() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(Group{}, "member", xypriv.LowFamiliar)
+	engine.AddRelation(nil, "member", xypriv.Admin)
+	var post = engine.AbstractResource("group_post")
+	post.SetContext(Group{})
+	post.SetPermission(xypriv.LowPrivate, "read")
+	post.SetPermission(xypriv.LowConfidential, "update")
+
+	var server = httptest.NewServer(pdp.NewServer(engine))
+	defer server.Close()
+
+	var client = pdp.NewClient(server.URL, server.Client())
+	var ctx = context.Background()
+
+	// Facts are matched against the context names of server engine.
+	fmt.Println(engine.ContextName(Group{}))
+	var read = pdp.CheckRequest{
+		Subject: pdp.SubjectDescriptor{ID: "alice"},
+		Relations: []pdp.RelationFact{
+			{Context: "Group", Owner: "bob", Relation: "member"},
+		},
+		Resource: pdp.ResourceDescriptor{Name: "group_post", Owner: "bob"},
+		Action:   []string{"read"},
+	}
+	var d, _ = client.Check(ctx, read)
+	fmt.Println(d.Relation, d.Allowed)
+
+	// A fact of the normal context doesn't apply in the group.
+	var update = read
+	update.Relations = []pdp.RelationFact{{Owner: "bob", Relation: "member"}}
+	update.Action = []string{"update"}
+	d, _ = client.Check(ctx, update)
+	fmt.Println(d.Relation, d.Allowed)
+
+	// Output:
+	// Group
+	// member true
+	// anyone false
+}
+
+func ExampleServer_mac() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(nil, "analyst", xypriv.LowFamiliar)
+	engine.SetMAC(xypriv.BellLaPadula)
+	engine.SetActionMode(xypriv.Read, "read")
+	var report = engine.AbstractResource("report")
+	report.SetPermission(xypriv.LowPrivate, "read")
+	report.SetClassification(xypriv.Label{Level: 2})
+
+	var server = httptest.NewServer(pdp.NewServer(engine))
+	defer server.Close()
+
+	// Remote subjects have the lowest clearance, so they can't read up.
+	var client = pdp.NewClient(server.URL, server.Client())
+	var err = client.Enforce(context.Background(), pdp.CheckRequest{
+		Subject:  pdp.SubjectDescriptor{ID: "alice"},
+		Relation: "analyst",
+		Resource: pdp.ResourceDescriptor{Name: "report"},
+		Action:   []string{"read"},
+	})
+	fmt.Println(err)
+	fmt.Println(errors.Is(err, xypriv.MACError))
+
+	// Output:
+	// MACError: alice can't read up report classified as 2{}
+	// true
+}
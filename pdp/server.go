@@ -0,0 +1,107 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package pdp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// Paths of Server endpoints.
+const (
+	CheckPath = "/v1/check"
+	BatchPath = "/v1/batch"
+)
+
+// MaxRequestSize bounds the body of requests to a Server in bytes.
+const MaxRequestSize = 1 << 20
+
+// Server is an http.Handler exposing the decisions of an engine.
+type Server struct {
+	engine *xypriv.Engine
+	mux    *http.ServeMux
+}
+
+// NewServer creates a Server evaluating requests against the engine.
+func NewServer(engine *xypriv.Engine) *Server {
+	var s = &Server{engine: engine, mux: http.NewServeMux()}
+	s.mux.HandleFunc(CheckPath, s.handleCheck)
+	s.mux.HandleFunc(BatchPath, s.handleBatch)
+	return s
+}
+
+// ServeHTTP implements http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleCheck evaluates a CheckRequest.
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	var req CheckRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	writeJSON(w, evaluate(r.Context(), s.engine, req))
+}
+
+// handleBatch evaluates a BatchRequest.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	var resp = BatchResponse{Decisions: make([]Decision, len(req.Requests))}
+	for i := range req.Requests {
+		resp.Decisions[i] = evaluate(r.Context(), s.engine, req.Requests[i])
+	}
+
+	writeJSON(w, resp)
+}
+
+// decode decodes the JSON body of a POST request, whose size is bounded by
+// MaxRequestSize. It writes the error response and returns false if it fails.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return false
+	}
+
+	var body = http.MaxBytesReader(w, r.Body, MaxRequestSize)
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// writeJSON writes v as the JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,391 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// normalContext is the name of the normal context in a Policy.
+const normalContext = "nil"
+
+// Policy is the declarative form of all relation mappings and abstract
+// resources. It can be exported, compared, and loaded again.
+type Policy struct {
+	// Relations maps a context name to its relations. The normal context is
+	// named "nil".
+	Relations map[string]map[Relation]Privilege `json:"relations"`
+
+	// Parents maps a context name to its parent context name.
+	Parents map[string]string `json:"parents,omitempty"`
+
+	// Mappings are the cross-context relation mappings, whose contexts are
+	// names.
+	Mappings []RelationMapping `json:"mappings,omitempty"`
+
+	// Lattices are the names of contexts having a lattice other than
+	// IntegerLattice. Lattices are objects, so they are neither compared by
+	// Diff nor loaded by LoadPolicy.
+	Lattices []string `json:"lattices,omitempty"`
+
+	// MAC is the MAC modes, ActionModes maps an action, whose elements are
+	// joined by "_", to its access mode.
+	MAC         MACMode               `json:"mac,omitempty"`
+	ActionModes map[string]AccessMode `json:"action_modes,omitempty"`
+
+	// Resources maps an abstract resource name to its details.
+	Resources map[string]PolicyResource `json:"resources"`
+}
+
+// PolicyResource is the declarative form of an abstract resource.
+type PolicyResource struct {
+	// Context is the context name of the resource.
+	Context string `json:"context"`
+
+	// Owner is the ID of owner, it is empty if the resource has no owner.
+	// Owners are loaded as SubjectRefs.
+	Owner string `json:"owner,omitempty"`
+
+	// Permissions maps an action, whose elements are joined by "_", to its
+	// access level.
+	Permissions map[string]AccessLevel `json:"permissions"`
+
+	// Classification and Integrity are the labels of resource in MAC modes,
+	// they are nil if the labels are the lowest ones.
+	Classification *Label `json:"classification,omitempty"`
+	Integrity      *Label `json:"integrity,omitempty"`
+}
+
+// NewPolicy returns an empty Policy.
+func NewPolicy() *Policy {
+	return &Policy{
+		Relations:   make(map[string]map[Relation]Privilege),
+		Parents:     make(map[string]string),
+		ActionModes: make(map[string]AccessMode),
+		Resources:   make(map[string]PolicyResource),
+	}
+}
+
+// CurrentPolicy returns a snapshot of all relation mappings and abstract
+// resources which are added to privilege manager.
+func CurrentPolicy() *Policy {
+	return defaultEngine.Policy()
+}
+
+// Policy returns a snapshot of all relation mappings and abstract resources of
+// the engine.
+func (e *Engine) Policy() *Policy {
+	e.mu.RLock()
+
+	var p = NewPolicy()
+	for cname, cmap := range e.relations {
+		p.Relations[cname] = make(map[Relation]Privilege)
+		for relation, privilege := range cmap {
+			p.Relations[cname][relation] = privilege
+		}
+	}
+
+	for _, rule := range e.mappings {
+		p.Mappings = append(p.Mappings, rule.RelationMapping)
+	}
+
+	for cname, l := range e.lattices {
+		if _, ok := l.(IntegerLattice); !ok {
+			p.Lattices = append(p.Lattices, cname)
+		}
+	}
+	sort.Strings(p.Lattices)
+
+	p.MAC = e.mac
+	for action, mode := range e.modes {
+		p.ActionModes[action] = mode
+	}
+
+	var parents = make(map[string]any, len(e.parents))
+	for cname, parent := range e.parents {
+		parents[cname] = parent
+	}
+
+	var contexts = make(map[string]any, len(e.resources))
+	var owners = make(map[string]Subject, len(e.resources))
+	for name, resource := range e.resources {
+		var pr = PolicyResource{
+			Permissions:    make(map[string]AccessLevel),
+			Classification: labelOf(resource.classification),
+			Integrity:      labelOf(resource.integrity),
+		}
+		for action, level := range resource.permissions {
+			pr.Permissions[action] = level
+		}
+		p.Resources[name] = pr
+		contexts[name] = resource.context
+		owners[name] = resource.owner
+	}
+	e.mu.RUnlock()
+
+	// Naming needs the lock of engine, so it is done after releasing it.
+	for cname, parent := range parents {
+		p.Parents[cname] = e.name(parent)
+	}
+
+	for name, ctx := range contexts {
+		var pr = p.Resources[name]
+		pr.Context = e.name(ctx)
+		pr.Owner = e.objectID(owners[name])
+		p.Resources[name] = pr
+	}
+
+	return p
+}
+
+// ReadPolicy decodes a JSON Policy from r.
+func ReadPolicy(r io.Reader) (*Policy, error) {
+	var p = NewPolicy()
+	if err := json.NewDecoder(r).Decode(p); err != nil {
+		return nil, ConfigurationError.Newf("invalid policy: %v", err)
+	}
+	return p, nil
+}
+
+// Write encodes the Policy to w in JSON format.
+func (p *Policy) Write(w io.Writer) error {
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(p)
+}
+
+// LoadPolicy adds all relation mappings and abstract resources of the Policy
+// to privilege manager. Contexts are loaded as their names.
+func LoadPolicy(p *Policy) {
+	defaultEngine.LoadPolicy(p)
+}
+
+// LoadPolicy adds all relation mappings and abstract resources of the Policy
+// to the engine, and sets its MAC modes. Contexts are loaded as their names.
+func (e *Engine) LoadPolicy(p *Policy) {
+	for cname, cmap := range p.Relations {
+		for relation, privilege := range cmap {
+			e.AddRelation(policyContext(cname), relation, privilege)
+		}
+	}
+
+	for cname, parent := range p.Parents {
+		e.SetContextParent(policyContext(cname), policyContext(parent))
+	}
+
+	for _, m := range p.Mappings {
+		if from, ok := m.From.(string); ok {
+			m.From = policyContext(from)
+		}
+		if to, ok := m.To.(string); ok {
+			m.To = policyContext(to)
+		}
+		e.AddRelationMapping(m)
+	}
+
+	if p.MAC != 0 {
+		e.SetMAC(p.MAC)
+	}
+	for action, mode := range p.ActionModes {
+		e.SetActionMode(mode, action)
+	}
+
+	for name, pr := range p.Resources {
+		var resource = e.AbstractResource(name)
+		resource.SetContext(policyContext(pr.Context))
+		if pr.Owner != "" {
+			resource.SetOwner(SubjectRef{ID: pr.Owner})
+		}
+		for action, level := range pr.Permissions {
+			resource.SetPermission(level, action)
+		}
+		if pr.Classification != nil {
+			resource.SetClassification(*pr.Classification)
+		}
+		if pr.Integrity != nil {
+			resource.SetIntegrity(*pr.Integrity)
+		}
+	}
+}
+
+// policyContext converts a context name in Policy to a context.
+func policyContext(cname string) any {
+	if cname == normalContext || cname == "" {
+		return nil
+	}
+	return cname
+}
+
+// privilege returns the privilege of relation in the context, it works the
+// same as getPrivilege but never panics.
+func (p *Policy) privilege(cname string, relation Relation) (Privilege, bool) {
+	if cname == "" {
+		cname = normalContext
+	}
+
+	relation = Relation(strings.ToLower(string(relation)))
+
+	var known = cname == normalContext
+	for _, c := range p.contextChain(cname) {
+		if cmap, ok := p.Relations[c]; ok {
+			known = true
+			if priv, ok := cmap[relation]; ok {
+				return priv, true
+			}
+		}
+	}
+
+	if !known {
+		return 0, false
+	}
+
+	priv, ok := defaultRelation[relation]
+	return priv, ok
+}
+
+// contextChain returns the context name and its ancestors, from the nearest
+// one. Cycles are cut at maxContextDepth.
+func (p *Policy) contextChain(cname string) []string {
+	var chain []string
+	for c := cname; c != "" && len(chain) < maxContextDepth; c = p.Parents[c] {
+		chain = append(chain, c)
+	}
+	return chain
+}
+
+// hasLattice returns true if the context has a lattice other than
+// IntegerLattice.
+func (p *Policy) hasLattice(cname string) bool {
+	for _, c := range p.Lattices {
+		if c == cname {
+			return true
+		}
+	}
+	return false
+}
+
+// actionMode returns the access mode of joined actions, untagged actions are
+// considered as ReadWrite.
+func (p *Policy) actionMode(actions string) AccessMode {
+	if mode, ok := p.ActionModes[actions]; ok {
+		return mode
+	}
+	return ReadWrite
+}
+
+// ancestorRelation is a relation of subject in an ancestor context of a
+// resource, which may be raised by a cross-context mapping.
+type ancestorRelation struct {
+	context  string
+	relation Relation
+}
+
+// String returns the description of relation.
+func (a ancestorRelation) String() string {
+	return fmt.Sprintf("%s in %s", a.relation, a.context)
+}
+
+// ancestorRelations returns the relations in ancestor contexts which are
+// mapped to the resource context by the mappings of Policy.
+func (p *Policy) ancestorRelations(cname string) []ancestorRelation {
+	var result []ancestorRelation
+	for _, m := range p.Mappings {
+		if a, ok := p.mappedFrom(m, cname); ok {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// mappedFrom returns the ancestor relation of mapping if it targets the
+// context.
+func (p *Policy) mappedFrom(m RelationMapping, cname string) (ancestorRelation, bool) {
+	var chain = p.contextChain(policyContextName(cname))
+	if len(chain) < 2 || fmt.Sprint(m.To) != chain[0] {
+		return ancestorRelation{}, false
+	}
+
+	for _, ancestor := range chain[1:] {
+		if fmt.Sprint(m.From) == ancestor {
+			return ancestorRelation{
+				context:  ancestor,
+				relation: Relation(strings.ToLower(string(m.Relation))),
+			}, true
+		}
+	}
+	return ancestorRelation{}, false
+}
+
+// allow returns true if relation in the resource context can perform action on
+// the abstract resource. If via is not nil, the subject also has its relation
+// in an ancestor context, so the mappings of Policy may raise the privilege
+// unless it is BadRelation.
+func (p *Policy) allow(resource string, relation Relation, action string, via *ancestorRelation) bool {
+	var pr, ok = p.Resources[resource]
+	if !ok {
+		return false
+	}
+
+	level, ok := pr.Permissions[action]
+	if !ok {
+		return false
+	}
+
+	privilege, ok := p.privilege(pr.Context, relation)
+	if !ok {
+		return false
+	}
+
+	if via != nil && privilege != BadRelation {
+		for _, m := range p.Mappings {
+			if a, ok := p.mappedFrom(m, pr.Context); ok && a == *via && m.Privilege > privilege {
+				privilege = m.Privilege
+			}
+		}
+	}
+
+	return int(privilege) >= int(level)
+}
+
+// policyContextName returns the name of context in Policy, an empty name is
+// the normal context.
+func policyContextName(cname string) string {
+	if cname == "" {
+		return normalContext
+	}
+	return cname
+}
+
+// addMapping appends the mapping unless the Policy already has it.
+func (p *Policy) addMapping(m RelationMapping) {
+	for _, existing := range p.Mappings {
+		if existing == m {
+			return
+		}
+	}
+	p.Mappings = append(p.Mappings, m)
+}
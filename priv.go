@@ -23,9 +23,9 @@
 package xypriv
 
 import (
-	"fmt"
+	"context"
 	"reflect"
-	"strings"
+	"time"
 )
 
 // Relation represents for the relation of a Subject over another one.
@@ -67,6 +67,36 @@ type DynamicResource interface {
 	Permission(s Subject, action ...string) AccessLevel
 }
 
+// FallibleStaticResource instances are StaticResources whose permission lookup
+// may fail, for example when it is backed by a remote store.
+type FallibleStaticResource interface {
+	StaticResource
+
+	// TryPermission works like Permission, but returns an error if the
+	// access level can't be found. It is preferred over Permission.
+	TryPermission(action ...string) (AccessLevel, error)
+}
+
+// FallibleDynamicResource instances are DynamicResources whose permission
+// lookup may fail.
+type FallibleDynamicResource interface {
+	DynamicResource
+
+	// TryPermission works like Permission, but returns an error if the
+	// access level can't be found. It is preferred over Permission.
+	TryPermission(s Subject, action ...string) (AccessLevel, error)
+}
+
+// DynamicResourceWithContext instances are DynamicResources whose permission
+// also receives the context.Context of check.
+type DynamicResourceWithContext interface {
+	DynamicResource
+
+	// PermissionWithContext works like Permission. It is preferred over
+	// Permission when the check is performed with Checker.OnContext.
+	PermissionWithContext(ctx context.Context, s Subject, action ...string) AccessLevel
+}
+
 // Subject instances are entities that want to perform action on Resource.
 type Subject interface {
 	// Relation returns the privilege value of the current Subject over passed
@@ -74,6 +104,37 @@ type Subject interface {
 	Relation(ctx any, s Subject) Relation
 }
 
+// Identified instances have a stable identity. When both the subject and the
+// owner are Identified with the same ID and the same type, the engine decides
+// the "self" relation without calling Relation. A SubjectRef matches any type
+// because it only knows the ID. The ID is also used in token binding, cache
+// keys, audit logs, and error messages.
+type Identified interface {
+	// SubjectID returns the identity of object, it must not be empty.
+	SubjectID() string
+}
+
+// FallibleSubject instances are Subjects whose relation lookup may fail, for
+// example when it is backed by a remote store.
+type FallibleSubject interface {
+	Subject
+
+	// TryRelation works like Relation, but returns an error if the relation
+	// can't be found. It is preferred over Relation.
+	TryRelation(ctx any, s Subject) (Relation, error)
+}
+
+// SubjectWithContext instances are Subjects whose relation lookup also
+// receives the context.Context of check, so that it can be cancelled or
+// traced.
+type SubjectWithContext interface {
+	Subject
+
+	// RelationWithContext works like Relation. The first parameter is the
+	// context.Context of check, the second one is the xypriv context.
+	RelationWithContext(ctx context.Context, c any, s Subject) Relation
+}
+
 // Delegatee instances helps to limit the privileges of a Subject.
 type Delegatee interface {
 	// Delegate returns true if the condition is allowed to perform, and vice
@@ -81,16 +142,28 @@ type Delegatee interface {
 	Delegate(relation Relation, resource Resource, action ...string) bool
 }
 
+// DelegateeWithContext instances are Delegatees which also receive the
+// context.Context of check, for example when they ask a remote service.
+type DelegateeWithContext interface {
+	Delegatee
+
+	// DelegateWithContext works like Delegate. It is preferred over Delegate.
+	DelegateWithContext(ctx context.Context, relation Relation, resource Resource, action ...string) bool
+}
+
 // Checker supports check if a subject can perform action on resource or not.
 type Checker struct {
+	engine    *Engine
 	subject   Subject
 	delegatee Delegatee
 	action    []string
+	atLeast   ConsistencyToken
+	asOf      time.Time
 }
 
 // Check returns a Checker with the subject.
 func Check(s Subject) *Checker {
-	return &Checker{subject: s}
+	return defaultEngine.Check(s)
 }
 
 // Delegate delegates the privileges to a delegatee.
@@ -107,68 +180,61 @@ func (c *Checker) Perform(action ...string) *Checker {
 
 // On checks if a subject can perform action on resource or not.
 func (c *Checker) On(resource Resource) error {
-	var accessLevel AccessLevel
-	switch t := resource.(type) {
-	case StaticResource:
-		accessLevel = t.Permission(c.action...)
-	case DynamicResource:
-		accessLevel = t.Permission(c.subject, c.action...)
-	default:
-		panic(NotImplementedError.New(
-			"expected an object implementing Resource or EnhancedResource"))
-	}
+	return c.Decide(resource).Err
+}
+
+// OnContext works like On, but the check is stopped with a CanceledError if
+// ctx is done. The ctx is passed to SubjectWithContext and
+// DynamicResourceWithContext.
+func (c *Checker) OnContext(ctx context.Context, resource Resource) error {
+	return c.DecideContext(ctx, resource).Err
+}
 
-	var ctx = resource.Context()
-	var owner = resource.Owner()
+// Decide checks if a subject can perform action on resource or not, then
+// returns the details of the decision.
+func (c *Checker) Decide(resource Resource) Decision {
+	return c.DecideContext(context.Background(), resource)
+}
 
-	if ctx == owner && owner != nil {
-		panic(XyprivError.New("do not use the owner as the context, you " +
-			"should set the context as nil in this case"))
+// DecideContext works like Decide, but the check is stopped with a
+// CanceledError if ctx is done.
+func (c *Checker) DecideContext(ctx context.Context, resource Resource) Decision {
+	if !c.asOf.IsZero() {
+		return c.decideAsOf(ctx, resource)
 	}
 
-	var privilege = Anyone
-	if c.subject != nil {
-		var relation = c.subject.Relation(ctx, owner)
-		privilege = getPrivilege(ctx, relation)
-		if c.delegatee != nil && !c.delegatee.Delegate(relation, resource, c.action...) {
-			return PermissionError.Newf(
-				"%s do not have the permission to %s %s",
-				getName(c.subject), strings.Join(c.action, "_"), getName(resource))
-		}
+	defer c.engine.observePanic()
+
+	var start = time.Now()
+	var d Decision
+	var rc, err = c.engine.revisions(ctx, c.atLeast)
+	if err != nil {
+		d = Decision{Subject: c.subject, Resource: resource, Action: c.action, Err: err}
+	} else if cache := c.engine.getCache(); cache != nil {
+		ctx = withRevisions(ctx, rc)
+		d = cache.decision(rc, c, resource, func() Decision {
+			return c.engine.decide(ctx, c, resource)
+		})
+	} else {
+		d = c.engine.decide(ctx, c, resource)
 	}
 
-	if int(privilege) < int(accessLevel) {
-		return PermissionError.Newf(
-			"%s do not have the permission to %s %s",
-			getName(c.subject), strings.Join(c.action, "_"), getName(resource))
+	c.engine.observe(d)
+	c.engine.audit(d, start)
+	c.engine.runShadow(c, resource, d)
+	return d
+}
+
+// isSelf returns true if both subject and owner are Identified with the same
+// ID and the same type. A SubjectRef matches any type.
+func isSelf(subject, owner Subject) bool {
+	var s, ok1 = subject.(Identified)
+	var o, ok2 = owner.(Identified)
+	if !ok1 || !ok2 || s.SubjectID() == "" || s.SubjectID() != o.SubjectID() {
+		return false
 	}
 
-	return nil
-}
-
-// getName returns the name of object.
-func getName(a any) string {
-	var name = func() string {
-		if a == nil {
-			return "nil"
-		}
-
-		if s, ok := a.(fmt.Stringer); ok {
-			return s.String()
-		}
-
-		var atype = reflect.TypeOf(a)
-		switch atype.Kind() {
-		case reflect.String:
-			return a.(string)
-		case reflect.Struct:
-			return atype.Name()
-		case reflect.Pointer:
-			return atype.Elem().Name()
-		default:
-			panic(XyprivError.New("expected a string, struct, or pointer of struct to get its name"))
-		}
-	}()
-
-	return strings.ReplaceAll(name, ".", "_")
+	var _, ref1 = subject.(SubjectRef)
+	var _, ref2 = owner.(SubjectRef)
+	return ref1 || ref2 || reflect.TypeOf(subject) == reflect.TypeOf(owner)
 }
@@ -0,0 +1,286 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tuple is a relationship stored in a RelationStore. It means that Subject has
+// Relation over Object in Context, and is written as
+// "context/object#relation@subject", the "context/" part is omitted for the
+// normal context.
+type Tuple struct {
+	// Context is the ID of context, it is empty for the normal context.
+	Context string `json:"context,omitempty"`
+
+	// Object is the ID of owner, it is empty if the owner is nil.
+	Object string `json:"object"`
+
+	// Relation is the relation of Subject over Object.
+	Relation Relation `json:"relation"`
+
+	// Subject is the ID of subject.
+	Subject string `json:"subject"`
+}
+
+// ParseTuple parses a tuple written as "context/object#relation@subject".
+func ParseTuple(s string) (Tuple, error) {
+	var t Tuple
+	var hash = strings.Index(s, "#")
+	var at = strings.LastIndex(s, "@")
+	if hash < 0 || at < hash {
+		return t, ConfigurationError.Newf("invalid tuple %q", s)
+	}
+
+	t.Object = s[:hash]
+	if slash := strings.LastIndex(t.Object, "/"); slash >= 0 {
+		t.Context, t.Object = t.Object[:slash], t.Object[slash+1:]
+	}
+	t.Relation = Relation(s[hash+1 : at])
+	t.Subject = s[at+1:]
+
+	if t.Relation == "" || t.Subject == "" {
+		return t, ConfigurationError.Newf("invalid tuple %q", s)
+	}
+	return t, nil
+}
+
+// String returns the tuple as "context/object#relation@subject".
+func (t Tuple) String() string {
+	var s = t.Object + "#" + string(t.Relation) + "@" + t.Subject
+	if t.Context != "" {
+		s = t.Context + "/" + s
+	}
+	return s
+}
+
+// match returns true if tuple has the context and object of filter, and the
+// non-empty relation and subject of filter.
+func (t Tuple) match(filter Tuple) bool {
+	return filter.Context == t.Context && filter.Object == t.Object &&
+		(filter.Relation == "" || filter.Relation == t.Relation) &&
+		(filter.Subject == "" || filter.Subject == t.Subject)
+}
+
+// NewTuple creates a tuple named by the default engine.
+func NewTuple(ctx any, owner Subject, relation Relation, subject string) Tuple {
+	return defaultEngine.NewTuple(ctx, owner, relation, subject)
+}
+
+// NewTuple creates a tuple meaning that subject has the relation over owner in
+// the context. Both context and owner are converted to IDs in the same way as
+// StoreSubject looks them up.
+func (e *Engine) NewTuple(ctx any, owner Subject, relation Relation, subject string) Tuple {
+	return Tuple{
+		Context:  e.objectID(ctx),
+		Object:   e.objectID(owner),
+		Relation: relation,
+		Subject:  subject,
+	}
+}
+
+// objectID returns the ID of object used in tuples, it is empty for nil.
+func (e *Engine) objectID(a any) string {
+	if a == nil {
+		return ""
+	}
+	return e.idOf(a)
+}
+
+// RelationStore instances store relationship tuples.
+type RelationStore interface {
+	// Write adds tuples to the store, existing tuples are ignored.
+	Write(tuples ...Tuple) error
+
+	// Delete removes tuples from the store, missing tuples are ignored.
+	Delete(tuples ...Tuple) error
+
+	// Read returns tuples having the context and object of filter. The
+	// relation and subject of filter are also matched if they are not empty.
+	Read(filter Tuple) ([]Tuple, error)
+}
+
+// MemoryStore is a RelationStore keeping tuples in memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[[2]string]map[Tuple]struct{}
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[[2]string]map[Tuple]struct{})}
+}
+
+// Write adds tuples to the store.
+func (s *MemoryStore) Write(tuples ...Tuple) error {
+	if err := validateTuples(tuples); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range tuples {
+		var key = [2]string{t.Context, t.Object}
+		if _, ok := s.objects[key]; !ok {
+			s.objects[key] = make(map[Tuple]struct{})
+		}
+		s.objects[key][t] = struct{}{}
+	}
+	return nil
+}
+
+// Delete removes tuples from the store.
+func (s *MemoryStore) Delete(tuples ...Tuple) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range tuples {
+		var key = [2]string{t.Context, t.Object}
+		delete(s.objects[key], t)
+		if len(s.objects[key]) == 0 {
+			delete(s.objects, key)
+		}
+	}
+	return nil
+}
+
+// Read returns tuples matching filter, sorted by their string forms.
+func (s *MemoryStore) Read(filter Tuple) ([]Tuple, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Tuple
+	for t := range s.objects[[2]string{filter.Context, filter.Object}] {
+		if t.match(filter) {
+			result = append(result, t)
+		}
+	}
+
+	sortTuples(result)
+	return result, nil
+}
+
+// all returns all tuples of the store, sorted by their string forms.
+func (s *MemoryStore) all() []Tuple {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []Tuple
+	for _, tuples := range s.objects {
+		for t := range tuples {
+			result = append(result, t)
+		}
+	}
+
+	sortTuples(result)
+	return result
+}
+
+// sortTuples sorts tuples by their string forms.
+func sortTuples(tuples []Tuple) {
+	sort.Slice(tuples, func(i, j int) bool {
+		return tuples[i].String() < tuples[j].String()
+	})
+}
+
+// validateTuples returns a ConfigurationError if any tuple lacks the relation
+// or the subject.
+func validateTuples(tuples []Tuple) error {
+	for _, t := range tuples {
+		if t.Relation == "" || t.Subject == "" {
+			return ConfigurationError.Newf("invalid tuple %q", t.String())
+		}
+	}
+	return nil
+}
+
+// StoreSubject is a Subject whose relations are the tuples of Store having the
+// subject ID, the context ID, and the owner ID. It needs no per-type Relation
+// code, stored tuples and AddRelation are enough.
+type StoreSubject struct {
+	// ID is the subject of tuples.
+	ID string
+
+	// Store contains the tuples.
+	Store RelationStore
+
+	// Engine names contexts and owners which aren't Identified. The default
+	// engine is used if it is nil.
+	Engine *Engine
+}
+
+// SubjectID implements Identified interface.
+func (s StoreSubject) SubjectID() string {
+	return s.ID
+}
+
+// Relation returns the first relation of subject over owner in the context. It
+// panics if the store fails.
+func (s StoreSubject) Relation(ctx any, owner Subject) Relation {
+	var relations = s.Relations(ctx, owner)
+	if len(relations) == 0 {
+		return "anyone"
+	}
+	return relations[0]
+}
+
+// Relations returns all relations of subject over owner in the context. It
+// panics if the store fails.
+func (s StoreSubject) Relations(ctx any, owner Subject) []Relation {
+	var relations, err = s.TryRelations(ctx, owner)
+	if err != nil {
+		panic(ResolutionError.New(err))
+	}
+	return relations
+}
+
+// TryRelations returns all relations of subject over owner in the context.
+func (s StoreSubject) TryRelations(ctx any, owner Subject) ([]Relation, error) {
+	var engine = s.Engine
+	if engine == nil {
+		engine = defaultEngine
+	}
+
+	if g, ok := s.Store.(*Graph); ok {
+		return g.Relations(engine.objectID(ctx), engine.objectID(owner), s.ID)
+	}
+
+	var tuples, err = s.Store.Read(Tuple{
+		Context: engine.objectID(ctx),
+		Object:  engine.objectID(owner),
+		Subject: s.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var relations = make([]Relation, 0, len(tuples))
+	for _, t := range tuples {
+		relations = append(relations, t.Relation)
+	}
+	return relations, nil
+}
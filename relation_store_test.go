@@ -0,0 +1,86 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// storePost implements StaticResource interface.
+type storePost struct {
+	author xypriv.Subject
+}
+
+// Context returns the normal context.
+func (p storePost) Context() any {
+	return nil
+}
+
+// Owner returns the author of post.
+func (p storePost) Owner() xypriv.Subject {
+	return p.author
+}
+
+// Permission returns LowPrivate, friends can read the post.
+func (p storePost) Permission(action ...string) xypriv.AccessLevel {
+	return xypriv.LowPrivate
+}
+
+func ExampleStoreSubject() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(nil, "friend", xypriv.LowFamiliar)
+	engine.AddRelation(nil, "banned", xypriv.BadRelation)
+	engine.SetRelationStrategy(xypriv.BadRelationWins)
+
+	var store = xypriv.NewMemoryStore()
+	var alice = xypriv.StoreSubject{ID: "alice", Store: store, Engine: engine}
+	var bob = xypriv.StoreSubject{ID: "bob", Store: store, Engine: engine}
+	var carol = xypriv.StoreSubject{ID: "carol", Store: store, Engine: engine}
+
+	var tuple, _ = xypriv.ParseTuple("alice#friend@bob")
+	store.Write(
+		tuple,
+		engine.NewTuple(nil, alice, "friend", "carol"),
+		engine.NewTuple(nil, alice, "banned", "carol"),
+	)
+
+	var post = storePost{author: alice}
+	fmt.Println(engine.Check(alice).Perform("read").On(post))
+	fmt.Println(engine.Check(bob).Perform("read").On(post))
+	fmt.Println(engine.Check(carol).Perform("read").On(post))
+
+	store.Delete(tuple)
+	fmt.Println(engine.Check(bob).Perform("read").On(post))
+
+	var tuples, _ = store.Read(xypriv.Tuple{Object: "alice"})
+	fmt.Println(tuples)
+
+	// Output:
+	// <nil>
+	// <nil>
+	// PermissionError: carol do not have the permission to read storePost
+	// PermissionError: bob do not have the permission to read storePost
+	// [alice#banned@carol alice#friend@carol]
+}
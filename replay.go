@@ -0,0 +1,247 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ReplayChange is a recorded decision which flips in the candidate engine.
+type ReplayChange struct {
+	Record AuditRecord `json:"record"`
+	After  bool        `json:"after"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ReplayGroup contains all changed decisions of a resource and a relation.
+type ReplayGroup struct {
+	Resource string         `json:"resource"`
+	Relation Relation       `json:"relation"`
+	Changes  []ReplayChange `json:"changes"`
+}
+
+// ReplayReport is the result of Replay.
+type ReplayReport struct {
+	// Records is the number of replayed records.
+	Records int `json:"records"`
+
+	// Skipped is the number of records which can't be replayed, they are
+	// failed-open decisions or errors other than PermissionError.
+	Skipped int `json:"skipped"`
+
+	// Groups are the changed decisions, grouped by resource and relation.
+	Groups []ReplayGroup `json:"groups"`
+}
+
+// Replay re-evaluates every AuditRecord in the JSON lines of r against the
+// candidate engine, then reports the decisions which would change. The
+// relations, owners, and delegatee outcomes are taken from records, contexts
+// and access levels are taken from the candidate if the resources are its
+// abstract resources.
+func Replay(r io.Reader, candidate *Engine) (ReplayReport, error) {
+	var report ReplayReport
+	var groups = make(map[[2]string]*ReplayGroup)
+
+	var scanner = bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return report, ConfigurationError.Newf("invalid audit record at line %d: %v", line, err)
+		}
+
+		if record.FailedOpen || (record.Error != "" &&
+			!errors.Is(NewClassError(record.ErrorClass, record.Error), PermissionError)) {
+			report.Skipped++
+			continue
+		}
+		report.Records++
+
+		var d = candidate.replay(record)
+		if d.Allowed == record.Allowed {
+			continue
+		}
+
+		var key = [2]string{record.Resource, string(record.Relation)}
+		if _, ok := groups[key]; !ok {
+			groups[key] = &ReplayGroup{Resource: record.Resource, Relation: record.Relation}
+		}
+
+		var change = ReplayChange{Record: record, After: d.Allowed}
+		if d.Err != nil && !d.Allowed {
+			change.Error = d.Err.Error()
+		}
+		groups[key].Changes = append(groups[key].Changes, change)
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+
+	for _, g := range groups {
+		report.Groups = append(report.Groups, *g)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].Resource != report.Groups[j].Resource {
+			return report.Groups[i].Resource < report.Groups[j].Resource
+		}
+		return report.Groups[i].Relation < report.Groups[j].Relation
+	})
+
+	return report, nil
+}
+
+// Empty returns true if no decision changes.
+func (r ReplayReport) Empty() bool {
+	return len(r.Groups) == 0
+}
+
+// WriteText writes the human-readable report to w.
+func (r ReplayReport) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "replayed %d records, skipped %d\n", r.Records, r.Skipped)
+	for _, g := range r.Groups {
+		fmt.Fprintf(&sb, "resource %s, relation %s:\n", g.Resource, g.Relation)
+		for _, c := range g.Changes {
+			fmt.Fprintf(&sb, "  %s %s by %s: %s -> %s\n", c.Record.Time.Format("2006-01-02T15:04:05Z07:00"),
+				c.Record.Action, c.Record.Subject, verdict(c.Record.Allowed), verdict(c.After))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteJSON writes the report to w in JSON format.
+func (r ReplayReport) WriteJSON(w io.Writer) error {
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// replay evaluates the recorded request against the engine.
+func (e *Engine) replay(record AuditRecord) Decision {
+	var resource Resource = replayResource{record: record}
+	if r, ok := e.FindAbstractResource(record.Resource); ok {
+		resource = r
+	}
+
+	var checker = e.Check(replaySubject{record: record})
+	if record.NilSubject {
+		checker = e.Check(nil)
+	}
+	if record.Delegatee != DelegateeNone {
+		checker.Delegate(replayDelegatee(record.Delegatee == DelegateeAllowed))
+	}
+	if record.Action != "" {
+		checker.Perform(strings.Split(record.Action, "_")...)
+	}
+
+	return e.safeDecide(context.Background(), checker, resource)
+}
+
+// replaySubject is a recorded subject, its relation and labels are the
+// recorded ones.
+type replaySubject struct {
+	record AuditRecord
+}
+
+// Relation returns the recorded relation.
+func (s replaySubject) Relation(ctx any, owner Subject) Relation {
+	return s.record.Relation
+}
+
+// Clearance implements Cleared interface.
+func (s replaySubject) Clearance() Label {
+	return recordedLabel(s.record.Clearance)
+}
+
+// Integrity implements IntegrityLabeled interface.
+func (s replaySubject) Integrity() Label {
+	return recordedLabel(s.record.SubjectIntegrity)
+}
+
+// replayResource is a recorded resource which isn't an abstract resource of
+// the candidate engine.
+type replayResource struct {
+	record AuditRecord
+}
+
+// Context returns the recorded context name.
+func (r replayResource) Context() any {
+	return policyContext(r.record.Context)
+}
+
+// Owner returns the recorded owner.
+func (r replayResource) Owner() Subject {
+	if r.record.Owner == "" {
+		return nil
+	}
+	return SubjectRef{ID: r.record.Owner}
+}
+
+// Permission returns the recorded access level.
+func (r replayResource) Permission(action ...string) AccessLevel {
+	return r.record.AccessLevel
+}
+
+// String returns the recorded resource name.
+func (r replayResource) String() string {
+	return r.record.Resource
+}
+
+// Classification implements Classified interface.
+func (r replayResource) Classification() Label {
+	return recordedLabel(r.record.Classification)
+}
+
+// Integrity implements IntegrityLabeled interface.
+func (r replayResource) Integrity() Label {
+	return recordedLabel(r.record.Integrity)
+}
+
+// recordedLabel returns the recorded label, or the lowest one if it is nil.
+func recordedLabel(l *Label) Label {
+	if l == nil {
+		return Label{}
+	}
+	return *l
+}
+
+// replayDelegatee returns the recorded delegatee outcome.
+type replayDelegatee bool
+
+// Delegate returns the recorded outcome.
+func (d replayDelegatee) Delegate(relation Relation, resource Resource, action ...string) bool {
+	return bool(d)
+}
@@ -0,0 +1,145 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// replayUser implements Subject interface.
+type replayUser struct {
+	id   string
+	role xypriv.Relation
+}
+
+// Relation returns the role of user.
+func (u replayUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return u.role
+}
+
+// SubjectID implements Identified interface.
+func (u replayUser) SubjectID() string {
+	return u.id
+}
+
+func ExampleReplay() {
+	var live = xypriv.NewEngine()
+	live.AddRelation(nil, "editor", xypriv.Moderator)
+	live.AddRelation(nil, "viewer", xypriv.LowFamiliar)
+	var article = live.AbstractResource("article")
+	article.SetPermission(xypriv.HighConfidential, "update")
+	article.SetPermission(xypriv.LowPrivate, "read")
+
+	var log bytes.Buffer
+	live.SetAuditor(xypriv.NewJSONLinesAuditor(&log))
+
+	var alice = replayUser{id: "alice", role: "editor"}
+	var bob = replayUser{id: "bob", role: "viewer"}
+	live.Check(alice).Perform("update").On(article)
+	live.Check(alice).Perform("read").On(article)
+	live.Check(bob).Perform("update").On(article)
+	live.Check(bob).Perform("read").On(article)
+
+	var candidate = xypriv.NewEngine()
+	candidate.LoadPolicy(live.Policy())
+	candidate.AddRelation(nil, "editor", xypriv.MediumFamiliar)
+
+	var report, _ = xypriv.Replay(&log, candidate)
+	for i := range report.Groups {
+		for j := range report.Groups[i].Changes {
+			report.Groups[i].Changes[j].Record.Time = time.Time{}
+		}
+	}
+	report.WriteText(os.Stdout)
+
+	// Output:
+	// replayed 4 records, skipped 0
+	// resource article, relation editor:
+	//   0001-01-01T00:00:00Z update by alice: allow -> deny
+}
+
+func ExampleReplay_classifiedErrors() {
+	var live = xypriv.NewEngine()
+	live.AddRelation(nil, "operator", xypriv.LowFamiliar)
+	live.AddRelation(nil, "viewer", xypriv.LowFamiliar)
+	live.SetMAC(xypriv.BellLaPadula)
+	live.SetActionMode(xypriv.Read, "read")
+	var notes = live.AbstractResource("notes")
+	notes.SetPermission(xypriv.LowPrivate, "read")
+
+	var log bytes.Buffer
+	live.SetAuditor(xypriv.NewJSONLinesAuditor(&log))
+
+	// The MAC denial is replayed as a permission denial.
+	live.Check(pipelineJob{name: "etl", clearance: 1}).Perform("read").On(dataset{classification: 3})
+
+	// A subject whose ID is "nil" isn't the nil subject.
+	live.Check(replayUser{id: "nil", role: "viewer"}).Perform("read").On(notes)
+	live.Check(nil).Perform("read").On(notes)
+
+	// The candidate disables MAC.
+	var candidate = xypriv.NewEngine()
+	candidate.LoadPolicy(live.Policy())
+	candidate.SetMAC(0)
+
+	var report, _ = xypriv.Replay(&log, candidate)
+	for i := range report.Groups {
+		for j := range report.Groups[i].Changes {
+			report.Groups[i].Changes[j].Record.Time = time.Time{}
+		}
+	}
+	report.WriteText(os.Stdout)
+
+	// Output:
+	// replayed 3 records, skipped 0
+	// resource dataset, relation operator:
+	//   0001-01-01T00:00:00Z read by etl: deny -> allow
+}
+
+func ExampleReplay_labels() {
+	var live = xypriv.NewEngine()
+	live.AddRelation(nil, "operator", xypriv.LowFamiliar)
+	live.SetMAC(xypriv.BellLaPadula)
+	live.SetActionMode(xypriv.Read, "read")
+
+	var log bytes.Buffer
+	live.SetAuditor(xypriv.NewJSONLinesAuditor(&log))
+
+	// The labels of subjects and resources are recorded.
+	var secret = dataset{classification: 2}
+	live.Check(pipelineJob{name: "etl", clearance: 2}).Perform("read").On(secret)
+	live.Check(pipelineJob{name: "report", clearance: 1}).Perform("read").On(secret)
+
+	var candidate = xypriv.NewEngine()
+	candidate.LoadPolicy(live.Policy())
+
+	var report, _ = xypriv.Replay(&log, candidate)
+	report.WriteText(os.Stdout)
+
+	// Output:
+	// replayed 2 records, skipped 0
+}
@@ -0,0 +1,75 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import "context"
+
+// requestKey is the type of keys stored in context.Context.
+type requestKey int
+
+const (
+	subjectKey requestKey = iota
+	tokenKey
+)
+
+// WithSubject returns a copy of ctx carrying the subject.
+func WithSubject(ctx context.Context, s Subject) context.Context {
+	return context.WithValue(ctx, subjectKey, s)
+}
+
+// SubjectFromContext returns the subject carried by ctx, or nil.
+func SubjectFromContext(ctx context.Context) Subject {
+	var s, _ = ctx.Value(subjectKey).(Subject)
+	return s
+}
+
+// WithToken returns a copy of ctx carrying the token.
+func WithToken(ctx context.Context, d Delegatee) context.Context {
+	return context.WithValue(ctx, tokenKey, d)
+}
+
+// TokenFromContext returns the token carried by ctx, or nil.
+func TokenFromContext(ctx context.Context) Delegatee {
+	var d, _ = ctx.Value(tokenKey).(Delegatee)
+	return d
+}
+
+// CheckContext returns a Checker with the subject and token carried by ctx.
+func CheckContext(ctx context.Context) *Checker {
+	return defaultEngine.CheckContext(ctx)
+}
+
+// CheckContext returns a Checker of the engine with the subject and token
+// carried by ctx.
+func (e *Engine) CheckContext(ctx context.Context) *Checker {
+	var c = e.Check(SubjectFromContext(ctx))
+	if d := TokenFromContext(ctx); d != nil {
+		c.Delegate(d)
+	}
+	return c
+}
+
+// canceled returns the CanceledError of the context error.
+func canceled(err error) error {
+	return CanceledError.Newf("check is stopped: %v", err)
+}
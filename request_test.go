@@ -0,0 +1,82 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// traceKey is the key of trace ID in context.Context.
+type traceKey struct{}
+
+// remoteUser implements SubjectWithContext interface. It looks its relations
+// up in a remote store.
+type remoteUser struct {
+	role string
+}
+
+// Relation returns the relation without a context.Context.
+func (u remoteUser) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return u.RelationWithContext(context.Background(), ctx, subject)
+}
+
+// RelationWithContext returns the relation, it prints the trace ID to show
+// that it receives the context.Context of check.
+func (u remoteUser) RelationWithContext(ctx context.Context, c any, s xypriv.Subject) xypriv.Relation {
+	if id, ok := ctx.Value(traceKey{}).(string); ok {
+		fmt.Println("lookup relation in trace", id)
+	}
+	return xypriv.Relation(u.role)
+}
+
+func ExampleChecker_OnContext() {
+	var engine = xypriv.NewEngine()
+	var table = engine.AbstractResource("account_table")
+	table.SetPermission(xypriv.HighSecret, "delete")
+
+	var token = xypriv.NewToken()
+	token.AllowAction("delete")
+
+	var ctx = context.WithValue(context.Background(), traceKey{}, "trace-1")
+	ctx = xypriv.WithSubject(ctx, remoteUser{role: "admin"})
+	ctx = xypriv.WithToken(ctx, token)
+
+	if engine.CheckContext(ctx).Perform("delete").OnContext(ctx, table) == nil {
+		fmt.Println("admin can delete the table")
+	}
+
+	var canceledCtx, cancel = context.WithCancel(ctx)
+	cancel()
+
+	var err = engine.CheckContext(canceledCtx).Perform("delete").OnContext(canceledCtx, table)
+	fmt.Println(errors.Is(err, xypriv.CanceledError))
+
+	// Output:
+	// lookup relation in trace trace-1
+	// admin can delete the table
+	// true
+}
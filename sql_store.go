@@ -0,0 +1,468 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// sqlTables are the statements creating tables of SQLStore, "%s" is replaced
+// by the prefix of tables.
+var sqlTables = []string{
+	`CREATE TABLE IF NOT EXISTS %srelations (
+		context VARCHAR(255) NOT NULL,
+		relation VARCHAR(255) NOT NULL,
+		privilege INTEGER NOT NULL,
+		PRIMARY KEY (context, relation)
+	)`,
+	`CREATE TABLE IF NOT EXISTS %sparents (
+		context VARCHAR(255) NOT NULL,
+		parent VARCHAR(255) NOT NULL,
+		PRIMARY KEY (context)
+	)`,
+	`CREATE TABLE IF NOT EXISTS %smappings (
+		from_context VARCHAR(255) NOT NULL,
+		relation VARCHAR(255) NOT NULL,
+		to_context VARCHAR(255) NOT NULL,
+		privilege INTEGER NOT NULL,
+		PRIMARY KEY (from_context, relation, to_context, privilege)
+	)`,
+	`CREATE TABLE IF NOT EXISTS %sresources (
+		name VARCHAR(255) NOT NULL,
+		context VARCHAR(255) NOT NULL,
+		owner VARCHAR(255) NOT NULL,
+		classification TEXT NOT NULL,
+		integrity TEXT NOT NULL,
+		PRIMARY KEY (name)
+	)`,
+	`CREATE TABLE IF NOT EXISTS %spermissions (
+		resource VARCHAR(255) NOT NULL,
+		action VARCHAR(255) NOT NULL,
+		level INTEGER NOT NULL,
+		PRIMARY KEY (resource, action)
+	)`,
+	`CREATE TABLE IF NOT EXISTS %stuples (
+		context VARCHAR(255) NOT NULL,
+		object VARCHAR(255) NOT NULL,
+		relation VARCHAR(255) NOT NULL,
+		subject VARCHAR(255) NOT NULL,
+		PRIMARY KEY (context, object, relation, subject)
+	)`,
+	`CREATE TABLE IF NOT EXISTS %stokens (
+		id VARCHAR(255) NOT NULL,
+		subject VARCHAR(255) NOT NULL,
+		rules TEXT NOT NULL,
+		revoked INTEGER NOT NULL,
+		PRIMARY KEY (id)
+	)`,
+}
+
+// SQLStore is a Store backed by database/sql. It only uses portable SQL, so
+// replacing a row is done by deleting and inserting it in a transaction.
+type SQLStore struct {
+	// DB is the database.
+	DB *sql.DB
+
+	// Prefix is prepended to table names, it is "xypriv_" by default.
+	Prefix string
+
+	// Placeholder returns the placeholder of the n-th argument, starting from
+	// 1. It is "?" by default, PostgreSQL needs "$n".
+	Placeholder func(n int) string
+}
+
+// NewSQLStore creates a SQLStore with the default prefix and placeholder.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{
+		DB:          db,
+		Prefix:      "xypriv_",
+		Placeholder: func(int) string { return "?" },
+	}
+}
+
+// CreateTables creates the tables of store if they don't exist.
+func (s *SQLStore) CreateTables() error {
+	for _, stmt := range sqlTables {
+		if _, err := s.DB.Exec(fmt.Sprintf(stmt, s.Prefix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write implements RelationStore interface.
+func (s *SQLStore) Write(tuples ...Tuple) error {
+	if err := validateTuples(tuples); err != nil {
+		return err
+	}
+
+	return s.transact(func(tx *sql.Tx) error {
+		for _, t := range tuples {
+			var args = []any{t.Context, t.Object, string(t.Relation), t.Subject}
+			if err := s.exec(tx, s.query("DELETE FROM %stuples WHERE context = %s "+
+				"AND object = %s AND relation = %s AND subject = %s", 4), args...); err != nil {
+				return err
+			}
+			if err := s.exec(tx, s.query("INSERT INTO %stuples (context, object, relation, "+
+				"subject) VALUES (%s, %s, %s, %s)", 4), args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete implements RelationStore interface.
+func (s *SQLStore) Delete(tuples ...Tuple) error {
+	return s.transact(func(tx *sql.Tx) error {
+		for _, t := range tuples {
+			if err := s.exec(tx, s.query("DELETE FROM %stuples WHERE context = %s "+
+				"AND object = %s AND relation = %s AND subject = %s", 4),
+				t.Context, t.Object, string(t.Relation), t.Subject); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Read implements RelationStore interface.
+func (s *SQLStore) Read(filter Tuple) ([]Tuple, error) {
+	var query = "SELECT context, object, relation, subject FROM %stuples " +
+		"WHERE context = %s AND object = %s"
+	var args = []any{filter.Context, filter.Object}
+	if filter.Relation != "" {
+		query += " AND relation = %s"
+		args = append(args, string(filter.Relation))
+	}
+	if filter.Subject != "" {
+		query += " AND subject = %s"
+		args = append(args, filter.Subject)
+	}
+
+	var tuples, err = s.readTuples(s.query(query, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	sortTuples(tuples)
+	return tuples, nil
+}
+
+// SaveRelation implements Store interface.
+func (s *SQLStore) SaveRelation(context string, relation Relation, privilege Privilege) error {
+	return s.transact(func(tx *sql.Tx) error {
+		if err := s.exec(tx, s.query("DELETE FROM %srelations WHERE context = %s "+
+			"AND relation = %s", 2), context, string(relation)); err != nil {
+			return err
+		}
+		return s.exec(tx, s.query("INSERT INTO %srelations (context, relation, privilege) "+
+			"VALUES (%s, %s, %s)", 3), context, string(relation), int(privilege))
+	})
+}
+
+// SaveParent implements Store interface.
+func (s *SQLStore) SaveParent(context, parent string) error {
+	return s.transact(func(tx *sql.Tx) error {
+		if err := s.exec(tx, s.query("DELETE FROM %sparents WHERE context = %s", 1), context); err != nil {
+			return err
+		}
+		if parent == "" {
+			return nil
+		}
+		return s.exec(tx, s.query("INSERT INTO %sparents (context, parent) VALUES (%s, %s)", 2),
+			context, parent)
+	})
+}
+
+// SaveMapping implements Store interface.
+func (s *SQLStore) SaveMapping(m RelationMapping) error {
+	var args = []any{fmt.Sprint(m.From), string(m.Relation), fmt.Sprint(m.To), int(m.Privilege)}
+	return s.transact(func(tx *sql.Tx) error {
+		if err := s.exec(tx, s.query("DELETE FROM %smappings WHERE from_context = %s "+
+			"AND relation = %s AND to_context = %s AND privilege = %s", 4), args...); err != nil {
+			return err
+		}
+		return s.exec(tx, s.query("INSERT INTO %smappings (from_context, relation, "+
+			"to_context, privilege) VALUES (%s, %s, %s, %s)", 4), args...)
+	})
+}
+
+// SaveResource implements Store interface.
+func (s *SQLStore) SaveResource(name string, r PolicyResource) error {
+	var classification, err = encodeLabel(r.Classification)
+	if err != nil {
+		return err
+	}
+	integrity, err := encodeLabel(r.Integrity)
+	if err != nil {
+		return err
+	}
+
+	return s.transact(func(tx *sql.Tx) error {
+		if err := s.exec(tx, s.query("DELETE FROM %sresources WHERE name = %s", 1), name); err != nil {
+			return err
+		}
+		if err := s.exec(tx, s.query("DELETE FROM %spermissions WHERE resource = %s", 1), name); err != nil {
+			return err
+		}
+		if err := s.exec(tx, s.query("INSERT INTO %sresources (name, context, owner, "+
+			"classification, integrity) VALUES (%s, %s, %s, %s, %s)", 5),
+			name, r.Context, r.Owner, classification, integrity); err != nil {
+			return err
+		}
+		for action, level := range r.Permissions {
+			if err := s.exec(tx, s.query("INSERT INTO %spermissions (resource, action, level) "+
+				"VALUES (%s, %s, %s)", 3), name, action, int(level)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SaveToken implements Store interface.
+func (s *SQLStore) SaveToken(t TokenRecord) error {
+	var rules, err = json.Marshal(t.Rules)
+	if err != nil {
+		return err
+	}
+
+	var revoked = 0
+	if t.Revoked {
+		revoked = 1
+	}
+
+	return s.transact(func(tx *sql.Tx) error {
+		if err := s.exec(tx, s.query("DELETE FROM %stokens WHERE id = %s", 1), t.ID); err != nil {
+			return err
+		}
+		return s.exec(tx, s.query("INSERT INTO %stokens (id, subject, rules, revoked) "+
+			"VALUES (%s, %s, %s, %s)", 4), t.ID, t.Subject, string(rules), revoked)
+	})
+}
+
+// Load implements Store interface.
+func (s *SQLStore) Load() (*Snapshot, error) {
+	var snapshot = NewSnapshot()
+	var policy = snapshot.Policy
+
+	var err = s.each(s.query("SELECT context, relation, privilege FROM %srelations", 0),
+		func(rows *sql.Rows) error {
+			var context, relation string
+			var privilege int
+			if err := rows.Scan(&context, &relation, &privilege); err != nil {
+				return err
+			}
+			if _, ok := policy.Relations[context]; !ok {
+				policy.Relations[context] = make(map[Relation]Privilege)
+			}
+			policy.Relations[context][Relation(relation)] = Privilege(privilege)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.each(s.query("SELECT context, parent FROM %sparents", 0),
+		func(rows *sql.Rows) error {
+			var context, parent string
+			if err := rows.Scan(&context, &parent); err != nil {
+				return err
+			}
+			policy.Parents[context] = parent
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.each(s.query("SELECT from_context, relation, to_context, privilege FROM %smappings "+
+		"ORDER BY from_context, relation, to_context, privilege", 0),
+		func(rows *sql.Rows) error {
+			var from, relation, to string
+			var privilege int
+			if err := rows.Scan(&from, &relation, &to, &privilege); err != nil {
+				return err
+			}
+			policy.Mappings = append(policy.Mappings, RelationMapping{
+				From:      from,
+				Relation:  Relation(relation),
+				To:        to,
+				Privilege: Privilege(privilege),
+			})
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.each(s.query("SELECT name, context, owner, classification, integrity FROM %sresources", 0),
+		func(rows *sql.Rows) error {
+			var name, classification, integrity string
+			var r = PolicyResource{Permissions: make(map[string]AccessLevel)}
+			if err := rows.Scan(&name, &r.Context, &r.Owner, &classification, &integrity); err != nil {
+				return err
+			}
+			var err error
+			if r.Classification, err = decodeLabel(classification); err != nil {
+				return ConfigurationError.Newf("invalid classification of resource %s: %v", name, err)
+			}
+			if r.Integrity, err = decodeLabel(integrity); err != nil {
+				return ConfigurationError.Newf("invalid integrity of resource %s: %v", name, err)
+			}
+			policy.Resources[name] = r
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.each(s.query("SELECT resource, action, level FROM %spermissions", 0),
+		func(rows *sql.Rows) error {
+			var resource, action string
+			var level int
+			if err := rows.Scan(&resource, &action, &level); err != nil {
+				return err
+			}
+			if r, ok := policy.Resources[resource]; ok {
+				r.Permissions[action] = AccessLevel(level)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Tuples, err = s.readTuples(s.query(
+		"SELECT context, object, relation, subject FROM %stuples", 0))
+	if err != nil {
+		return nil, err
+	}
+	sortTuples(snapshot.Tuples)
+
+	err = s.each(s.query("SELECT id, subject, rules, revoked FROM %stokens", 0),
+		func(rows *sql.Rows) error {
+			var t TokenRecord
+			var rules string
+			var revoked int
+			if err := rows.Scan(&t.ID, &t.Subject, &rules, &revoked); err != nil {
+				return err
+			}
+			if err := json.Unmarshal([]byte(rules), &t.Rules); err != nil {
+				return ConfigurationError.Newf("invalid rules of token %s: %v", t.ID, err)
+			}
+			t.Revoked = revoked != 0
+			snapshot.Tokens = append(snapshot.Tokens, t)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	sortTokens(snapshot.Tokens)
+
+	return snapshot, nil
+}
+
+// query formats the query with the table prefix and n placeholders.
+func (s *SQLStore) query(query string, n int) string {
+	var args = []any{s.Prefix}
+	for i := 1; i <= n; i++ {
+		args = append(args, s.Placeholder(i))
+	}
+	return fmt.Sprintf(query, args...)
+}
+
+// transact runs f in a transaction, which is committed if f succeeds.
+func (s *SQLStore) transact(f func(tx *sql.Tx) error) error {
+	var tx, err = s.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// exec executes the statement in the transaction.
+func (s *SQLStore) exec(tx *sql.Tx, query string, args ...any) error {
+	var _, err = tx.Exec(query, args...)
+	return err
+}
+
+// each calls f for every row of the query.
+func (s *SQLStore) each(query string, f func(rows *sql.Rows) error, args ...any) error {
+	var rows, err = s.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := f(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// readTuples returns the tuples of the query.
+func (s *SQLStore) readTuples(query string, args ...any) ([]Tuple, error) {
+	var tuples []Tuple
+	var err = s.each(query, func(rows *sql.Rows) error {
+		var t Tuple
+		var relation string
+		if err := rows.Scan(&t.Context, &t.Object, &relation, &t.Subject); err != nil {
+			return err
+		}
+		t.Relation = Relation(relation)
+		tuples = append(tuples, t)
+		return nil
+	}, args...)
+	return tuples, err
+}
+
+// encodeLabel returns the JSON form of label, or an empty string if it is nil.
+func encodeLabel(l *Label) (string, error) {
+	if l == nil {
+		return "", nil
+	}
+	var b, err = json.Marshal(l)
+	return string(b), err
+}
+
+// decodeLabel parses the JSON form of label, an empty string is nil.
+func decodeLabel(s string) (*Label, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var l Label
+	if err := json.Unmarshal([]byte(s), &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
@@ -0,0 +1,165 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// stubDriver is a database/sql driver which logs executed statements and
+// answers queries with canned rows, chosen by the table name in the query.
+type stubDriver struct {
+	log  []string
+	rows map[string][][]driver.Value
+}
+
+// Open implements driver.Driver interface.
+func (d *stubDriver) Open(name string) (driver.Conn, error) {
+	return stubConn{d}, nil
+}
+
+// stubConn implements driver.Conn interface.
+type stubConn struct {
+	driver *stubDriver
+}
+
+func (c stubConn) Prepare(query string) (driver.Stmt, error) {
+	return stubStmt{c.driver, query}, nil
+}
+
+func (c stubConn) Close() error              { return nil }
+func (c stubConn) Begin() (driver.Tx, error) { return stubTx{}, nil }
+
+// stubTx implements driver.Tx interface.
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+// stubStmt implements driver.Stmt interface.
+type stubStmt struct {
+	driver *stubDriver
+	query  string
+}
+
+func (s stubStmt) Close() error  { return nil }
+func (s stubStmt) NumInput() int { return -1 }
+
+func (s stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.log = append(s.driver.log, fmt.Sprint(s.query, " ", args))
+	return driver.RowsAffected(1), nil
+}
+
+func (s stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	for table, rows := range s.driver.rows {
+		if strings.Contains(s.query, "FROM "+table) {
+			return &stubRows{rows: rows}, nil
+		}
+	}
+	return &stubRows{}, nil
+}
+
+// stubRows implements driver.Rows interface.
+type stubRows struct {
+	rows [][]driver.Value
+}
+
+func (r *stubRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *stubRows) Close() error { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func ExampleSQLStore() {
+	var stub = &stubDriver{rows: map[string][][]driver.Value{
+		"xypriv_relations":   {{"nil", "editor", int64(7)}},
+		"xypriv_parents":     {{"team", "org"}},
+		"xypriv_mappings":    {{"org", "orgadmin", "team", int64(8)}},
+		"xypriv_resources":   {{"article", "nil", "alice", `{"level":2}`, ""}},
+		"xypriv_permissions": {{"article", "update", int64(7)}},
+		"xypriv_tuples":      {{"", "alice", "editor", "bob"}},
+		"xypriv_tokens": {
+			{"42", "bob", `{"read..":true}`, int64(1)},
+			{"9", "", `{}`, int64(0)},
+		},
+	}}
+	sql.Register("xyprivstub", stub)
+	var db, _ = sql.Open("xyprivstub", "")
+
+	var store = xypriv.NewSQLStore(db)
+	store.Placeholder = func(n int) string { return fmt.Sprintf("$%d", n) }
+	store.SaveRelation("nil", "editor", xypriv.Moderator)
+	store.SaveParent("team", "org")
+	store.Write(xypriv.Tuple{Object: "alice", Relation: "editor", Subject: "bob"})
+	for _, stmt := range stub.log {
+		fmt.Println(stmt)
+	}
+
+	var snapshot, _ = store.Load()
+	var article = snapshot.Policy.Resources["article"]
+	fmt.Println(snapshot.Policy.Relations, article.Context, article.Owner, article.Permissions)
+	fmt.Println(*article.Classification, article.Integrity)
+	fmt.Println(snapshot.Policy.Parents, snapshot.Policy.Mappings)
+	fmt.Println(snapshot.Tuples, snapshot.Tokens)
+
+	var tuples, _ = store.Read(xypriv.Tuple{Object: "alice", Subject: "bob"})
+	fmt.Println(tuples)
+
+	var engine = xypriv.NewEngine()
+	engine.SetStore(store)
+	var resource, _ = engine.FindAbstractResource("article")
+	var bob = xypriv.StoreSubject{ID: "bob", Store: store, Engine: engine}
+	fmt.Println(engine.Check(bob).Perform("update").On(resource))
+
+	// Output:
+	// DELETE FROM xypriv_relations WHERE context = $1 AND relation = $2 [nil editor]
+	// INSERT INTO xypriv_relations (context, relation, privilege) VALUES ($1, $2, $3) [nil editor 7]
+	// DELETE FROM xypriv_parents WHERE context = $1 [team]
+	// INSERT INTO xypriv_parents (context, parent) VALUES ($1, $2) [team org]
+	// DELETE FROM xypriv_tuples WHERE context = $1 AND object = $2 AND relation = $3 AND subject = $4 [ alice editor bob]
+	// INSERT INTO xypriv_tuples (context, object, relation, subject) VALUES ($1, $2, $3, $4) [ alice editor bob]
+	// map[nil:map[editor:7]] nil alice map[update:7]
+	// 2{} <nil>
+	// map[team:org] [relation orgadmin in context org maps to privilege 8 in context team]
+	// [alice#editor@bob] [{9  map[] false} {42 bob map[read..:true] true}]
+	// [alice#editor@bob]
+	// <nil>
+}
@@ -0,0 +1,284 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"sort"
+	"strconv"
+	"sync/atomic"
+)
+
+// Store instances persist relation mappings, context parents, cross-context
+// mappings, abstract resources, tuples, and tokens, so that an engine can be
+// restored after restart.
+type Store interface {
+	RelationStore
+
+	// SaveRelation persists the privilege of relation in the context name.
+	SaveRelation(context string, relation Relation, privilege Privilege) error
+
+	// SaveParent persists the parent name of the context name. An empty
+	// parent removes it.
+	SaveParent(context, parent string) error
+
+	// SaveMapping persists the cross-context mapping whose contexts are
+	// names. Saving the same mapping again has no effect.
+	SaveMapping(m RelationMapping) error
+
+	// SaveResource persists the abstract resource, replacing the old one.
+	SaveResource(name string, r PolicyResource) error
+
+	// SaveToken persists the token, replacing the old one.
+	SaveToken(t TokenRecord) error
+
+	// Load returns everything persisted in the store.
+	Load() (*Snapshot, error)
+}
+
+// Snapshot is the content of a Store.
+type Snapshot struct {
+	// Policy contains relation mappings, context parents, cross-context
+	// mappings, and abstract resources.
+	Policy *Policy `json:"policy"`
+
+	// Tuples are the relationship tuples.
+	Tuples []Tuple `json:"tuples"`
+
+	// Tokens are the persisted tokens.
+	Tokens []TokenRecord `json:"tokens"`
+}
+
+// NewSnapshot returns an empty Snapshot.
+func NewSnapshot() *Snapshot {
+	return &Snapshot{Policy: NewPolicy()}
+}
+
+// TokenRecord is the persisted form of a LeastPrivilegeToken.
+type TokenRecord struct {
+	ID      string          `json:"id"`
+	Subject string          `json:"subject,omitempty"`
+	Rules   map[string]bool `json:"rules"`
+	Revoked bool            `json:"revoked,omitempty"`
+}
+
+// sortTokens sorts the tokens by their numeric IDs.
+func sortTokens(tokens []TokenRecord) {
+	sort.Slice(tokens, func(i, j int) bool {
+		var a, errA = strconv.ParseUint(tokens[i].ID, 10, 64)
+		var b, errB = strconv.ParseUint(tokens[j].ID, 10, 64)
+		if errA != nil || errB != nil || a == b {
+			return tokens[i].ID < tokens[j].ID
+		}
+		return a < b
+	})
+}
+
+// SubjectRef is a Subject known by its ID only, such as an owner loaded from a
+// Store. It has the "anyone" relation over others, so that it only works as an
+// owner or with StoreSubjects.
+type SubjectRef struct {
+	ID string
+}
+
+// Relation returns "anyone".
+func (s SubjectRef) Relation(ctx any, owner Subject) Relation {
+	return "anyone"
+}
+
+// SubjectID implements Identified interface.
+func (s SubjectRef) SubjectID() string {
+	return s.ID
+}
+
+// SetStore sets the store of the default engine.
+func SetStore(s Store) error {
+	return defaultEngine.SetStore(s)
+}
+
+// SetStore loads the policy and tokens from the store into the engine, then
+// writes their changes through to the store. Changes applied from a
+// Broadcaster aren't written. Pass nil to stop writing.
+func (e *Engine) SetStore(s Store) error {
+	if s == nil {
+		e.mu.Lock()
+		e.store = nil
+		e.mu.Unlock()
+		return nil
+	}
+
+	var snapshot, err = s.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, record := range snapshot.Tokens {
+		if _, err := tokenID(record.ID); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	e.store = nil
+	e.mu.Unlock()
+
+	e.LoadPolicy(snapshot.Policy)
+	for _, record := range snapshot.Tokens {
+		if err := e.loadToken(record); err != nil {
+			return err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.store = s
+	e.storeErr = nil
+	return nil
+}
+
+// StoreError returns the first error of writing through to the store, or nil.
+func (e *Engine) StoreError() error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.storeErr
+}
+
+// FindToken returns the token having the ID, which is loaded from the store or
+// created after the store is set.
+func (e *Engine) FindToken(id string) (*LeastPrivilegeToken, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var t, ok = e.tokens[id]
+	return t, ok
+}
+
+// tokenID parses the ID of a token record.
+func tokenID(id string) (uint64, error) {
+	var n, err = strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, ConfigurationError.Newf("invalid token id %q", id)
+	}
+	return n, nil
+}
+
+// loadToken restores the token of record. It returns a ConfigurationError if
+// the ID of record is invalid.
+func (e *Engine) loadToken(record TokenRecord) error {
+	var id, err = tokenID(record.ID)
+	if err != nil {
+		return err
+	}
+
+	// New tokens must not reuse the IDs of loaded ones.
+	for {
+		var current = atomic.LoadUint64(&tokenCounter)
+		if current >= id || atomic.CompareAndSwapUint64(&tokenCounter, current, id) {
+			break
+		}
+	}
+
+	var t = &LeastPrivilegeToken{
+		id:      id,
+		subject: record.Subject,
+		rules:   make(map[string]bool, len(record.Rules)),
+		engine:  e,
+	}
+	for k, v := range record.Rules {
+		t.rules[k] = v
+	}
+	e.coverage.trackToken(t)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tokens[record.ID] = t
+	if record.Revoked {
+		e.revoked[record.ID] = struct{}{}
+	}
+	return nil
+}
+
+// persist writes a change through to the store of engine if any. The first
+// error is kept.
+func (e *Engine) persist(f func(s Store) error) {
+	e.mu.RLock()
+	var s = e.store
+	e.mu.RUnlock()
+
+	if s == nil {
+		return
+	}
+
+	if err := f(s); err != nil {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if e.storeErr == nil {
+			e.storeErr = err
+		}
+	}
+}
+
+// persistResource writes the abstract resource through to the store.
+func (e *Engine) persistResource(name string) {
+	e.persist(func(s Store) error {
+		e.mu.RLock()
+		var r, ok = e.resources[name]
+		var pr = PolicyResource{
+			Permissions:    make(map[string]AccessLevel, len(r.permissions)),
+			Classification: labelOf(r.classification),
+			Integrity:      labelOf(r.integrity),
+		}
+		for action, level := range r.permissions {
+			pr.Permissions[action] = level
+		}
+		e.mu.RUnlock()
+
+		if !ok {
+			return nil
+		}
+
+		pr.Context = e.name(r.context)
+		pr.Owner = e.objectID(r.owner)
+		return s.SaveResource(name, pr)
+	})
+}
+
+// persistToken writes the token through to the store. Tokens are registered
+// in the engine when they are persisted.
+func (e *Engine) persistToken(t *LeastPrivilegeToken) {
+	e.persist(func(s Store) error {
+		var record = TokenRecord{
+			ID:      t.TokenID(),
+			Subject: t.subject,
+			Rules:   make(map[string]bool, len(t.rules)),
+			Revoked: e.isRevoked(t.TokenID()),
+		}
+		for k, v := range t.rules {
+			record.Rules[k] = v
+		}
+
+		e.mu.Lock()
+		e.tokens[record.ID] = t
+		e.mu.Unlock()
+
+		return s.SaveToken(record)
+	})
+}
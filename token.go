@@ -22,20 +22,74 @@
 
 package xypriv
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// tokenCounter generates the identifiers of tokens.
+var tokenCounter uint64
 
 // LeastPrivilegeToken is a Token implements Delegatee. It uses the principle of
 // least privilege.
 type LeastPrivilegeToken struct {
-	rules map[string]bool
+	id      uint64
+	subject string
+	rules   map[string]bool
+	engine  *Engine
+}
+
+// SubjectBinder instances are Delegatees which only can be used by a subject.
+type SubjectBinder interface {
+	// BoundSubject returns the ID of subject which can use the Delegatee. An
+	// empty ID means any subject.
+	BoundSubject() string
 }
 
 // NewToken creates a LeastPrivilegeToken that implements Delegatee. It uses the
 // principle of least privilege. By default, all privileges is rejected.
 func NewToken() *LeastPrivilegeToken {
-	return &LeastPrivilegeToken{
-		rules: make(map[string]bool),
+	return defaultEngine.NewToken()
+}
+
+// NewToken creates a LeastPrivilegeToken whose scopes are named by the engine
+// namer and whose rules are tracked by the engine coverage.
+func (e *Engine) NewToken() *LeastPrivilegeToken {
+	var t = &LeastPrivilegeToken{
+		id:     atomic.AddUint64(&tokenCounter, 1),
+		rules:  make(map[string]bool),
+		engine: e,
 	}
+	e.coverage.trackToken(t)
+	e.persistToken(t)
+	e.publishToken(t)
+
+	return t
+}
+
+// TokenID implements Revocable interface.
+func (t LeastPrivilegeToken) TokenID() string {
+	return strconv.FormatUint(t.id, 10)
+}
+
+// Revoke revokes the token in its engine, so that all checks delegated to the
+// token are denied. Engines following the same Broadcaster also revoke it.
+func (t LeastPrivilegeToken) Revoke() {
+	t.getEngine().RevokeToken(t.TokenID())
+}
+
+// Bind binds the token to the subject ID, so that only the Identified subject
+// with this ID can use it.
+func (t *LeastPrivilegeToken) Bind(subjectID string) {
+	t.subject = subjectID
+	t.getEngine().persistToken(t)
+	t.getEngine().publishToken(t)
+}
+
+// BoundSubject implements SubjectBinder interface.
+func (t LeastPrivilegeToken) BoundSubject() string {
+	return t.subject
 }
 
 // AllowAction allows all privileges on action.
@@ -91,8 +145,8 @@ func (t *LeastPrivilegeToken) Ban(relation Relation, scope any, action ...string
 // Delegate checks if condition tuple is allowed or banned.
 func (t LeastPrivilegeToken) Delegate(relation Relation, resource Resource, action ...string) bool {
 	var relName = string(relation)
-	var rsrName = getName(resource)
-	var ctxName = getName(resource.Context())
+	var rsrName = t.getEngine().name(resource)
+	var ctxName = t.getEngine().name(resource.Context())
 	var actName = strings.Join(action, "_")
 
 	var keys = []string{
@@ -115,6 +169,7 @@ func (t LeastPrivilegeToken) Delegate(relation Relation, resource Resource, acti
 	var isAllow = false
 	for _, k := range keys {
 		if val, ok := t.rules[k]; ok {
+			t.getEngine().coverage.hitRule(t.id, k)
 			if !val {
 				return false
 			}
@@ -125,6 +180,24 @@ func (t LeastPrivilegeToken) Delegate(relation Relation, resource Resource, acti
 	return isAllow
 }
 
+// getEngine returns the engine creating the token, or the default engine.
+func (t LeastPrivilegeToken) getEngine() *Engine {
+	if t.engine != nil {
+		return t.engine
+	}
+	return defaultEngine
+}
+
+// bound returns true if the subject can use the SubjectBinder.
+func bound(b SubjectBinder, subject Subject) bool {
+	if b.BoundSubject() == "" {
+		return true
+	}
+
+	var i, ok = subject.(Identified)
+	return ok && i.SubjectID() == b.BoundSubject()
+}
+
 // setRule adds the condition tuple of relation, scope, and action into token
 // rules. The scope could be context or resource. Use the empty relation to
 // apply all relations in the condition. Use the empty string as scope to apply
@@ -132,8 +205,10 @@ func (t LeastPrivilegeToken) Delegate(relation Relation, resource Resource, acti
 // condition.
 func (t *LeastPrivilegeToken) setRule(relation Relation, scope any, action []string, result bool) {
 	var relName = string(relation)
-	var scopeName = getName(scope)
+	var scopeName = t.getEngine().name(scope)
 	var actName = strings.Join(action, "_")
 
 	t.rules[strings.Join([]string{actName, relName, scopeName}, ".")] = result
+	t.getEngine().persistToken(t)
+	t.getEngine().publishToken(t)
 }
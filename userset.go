@@ -0,0 +1,463 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// defaultGraphDepth is the default depth limit of Graph.
+const defaultGraphDepth = 32
+
+// usersetOp is the operation of a Userset.
+type usersetOp int
+
+const (
+	opThis usersetOp = iota
+	opComputed
+	opTupleToUserset
+	opUnion
+	opIntersection
+	opExclusion
+)
+
+// String returns the name of operation.
+func (op usersetOp) String() string {
+	switch op {
+	case opThis:
+		return "this"
+	case opComputed:
+		return "computed"
+	case opTupleToUserset:
+		return "tupleToUserset"
+	case opUnion:
+		return "union"
+	case opIntersection:
+		return "intersection"
+	case opExclusion:
+		return "exclusion"
+	}
+	return "unknown"
+}
+
+// Userset is a rewrite rule computing the subjects having a relation over an
+// object.
+type Userset struct {
+	op       usersetOp
+	relation Relation
+	tupleset Relation
+	children []Userset
+}
+
+// This returns the subjects of stored tuples having the relation, subject sets
+// such as "group:7#member" are expanded. It is the rule of relations without
+// any rewrite.
+func This() Userset {
+	return Userset{op: opThis}
+}
+
+// ComputedUserset returns the subjects having another relation over the same
+// object, for example editors are also viewers.
+func ComputedUserset(relation Relation) Userset {
+	return Userset{op: opComputed, relation: relation}
+}
+
+// TupleToUserset returns the subjects having the computed relation over every
+// subject of the tupleset relation. For example, friends of friends are
+// TupleToUserset("friend", "friend").
+func TupleToUserset(tupleset, computed Relation) Userset {
+	return Userset{op: opTupleToUserset, relation: computed, tupleset: tupleset}
+}
+
+// Union returns the subjects of any userset.
+func Union(usersets ...Userset) Userset {
+	return Userset{op: opUnion, children: usersets}
+}
+
+// Intersection returns the subjects of all usersets.
+func Intersection(usersets ...Userset) Userset {
+	return Userset{op: opIntersection, children: usersets}
+}
+
+// Exclusion returns the subjects of base which aren't in subtract.
+func Exclusion(base, subtract Userset) Userset {
+	return Userset{op: opExclusion, children: []Userset{base, subtract}}
+}
+
+// Graph is a RelationStore evaluating userset rewrites over another store by
+// graph traversal. Write, Delete, and Read are forwarded to the store, so Read
+// only returns stored tuples. StoreSubjects backed by a Graph also have the
+// computed relations.
+type Graph struct {
+	// Store contains the stored tuples.
+	Store RelationStore
+
+	// MaxDepth limits the depth of traversal, 32 is used if it is not
+	// positive. Checks exceeding the limit fail with a ResolutionError.
+	MaxDepth int
+
+	rules map[Relation]Userset
+}
+
+// NewGraph creates a Graph over the store.
+func NewGraph(store RelationStore) *Graph {
+	return &Graph{Store: store, rules: make(map[Relation]Userset)}
+}
+
+// Rewrite sets the rule of relation in every context. Relations without any
+// rule are evaluated by This.
+func (g *Graph) Rewrite(relation Relation, rule Userset) {
+	g.rules[relation] = rule
+}
+
+// Write adds tuples to the store.
+func (g *Graph) Write(tuples ...Tuple) error {
+	return g.Store.Write(tuples...)
+}
+
+// Delete removes tuples from the store.
+func (g *Graph) Delete(tuples ...Tuple) error {
+	return g.Store.Delete(tuples...)
+}
+
+// Read returns stored tuples matching filter, rewrites aren't evaluated.
+func (g *Graph) Read(filter Tuple) ([]Tuple, error) {
+	return g.Store.Read(filter)
+}
+
+// Check returns true if the subject of tuple has the relation over the object
+// in the context, rewrites are evaluated.
+func (g *Graph) Check(t Tuple) (bool, error) {
+	var w = g.newWalk()
+	return w.check(t.Context, t.Object, t.Relation, t.Subject)
+}
+
+// Relations returns all relations of subject over object in the context,
+// including computed relations.
+func (g *Graph) Relations(context, object, subject string) ([]Relation, error) {
+	var stored, err = g.Store.Read(Tuple{Context: context, Object: object})
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates = make(map[Relation]struct{})
+	for _, t := range stored {
+		candidates[t.Relation] = struct{}{}
+	}
+	for relation := range g.rules {
+		candidates[relation] = struct{}{}
+	}
+
+	var relations []Relation
+	for relation := range candidates {
+		var ok, err = g.newWalk().check(context, object, relation, subject)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			relations = append(relations, relation)
+		}
+	}
+
+	sort.Slice(relations, func(i, j int) bool { return relations[i] < relations[j] })
+	return relations, nil
+}
+
+// Expand returns the tree of subjects having the relation over object in the
+// context, it helps to debug rewrites.
+func (g *Graph) Expand(context, object string, relation Relation) (*ExpandNode, error) {
+	var w = g.newWalk()
+	return w.expandRelation(context, object, relation)
+}
+
+// ExpandNode is a node of the tree returned by Graph.Expand.
+type ExpandNode struct {
+	// Operation is the operation of node, such as "this", "union", or
+	// "cycle".
+	Operation string
+
+	// Userset is the userset of node, written as "context/object#relation".
+	// It is empty for set operations.
+	Userset string
+
+	// Subjects are subjects of stored tuples, it is only set for "this".
+	Subjects []string
+
+	// Children are the expanded operands or subject sets.
+	Children []*ExpandNode
+}
+
+// String returns the tree as indented text.
+func (n *ExpandNode) String() string {
+	var b strings.Builder
+	n.write(&b, 0)
+	return b.String()
+}
+
+// write writes the node and its children to w.
+func (n *ExpandNode) write(w io.Writer, depth int) {
+	fmt.Fprintf(w, "%s%s", strings.Repeat("  ", depth), n.Operation)
+	if n.Userset != "" {
+		fmt.Fprintf(w, " %s", n.Userset)
+	}
+	if len(n.Subjects) > 0 {
+		fmt.Fprintf(w, " %v", n.Subjects)
+	}
+	fmt.Fprintln(w)
+
+	for _, child := range n.Children {
+		child.write(w, depth+1)
+	}
+}
+
+// walk is a traversal of Graph, it tracks the current path to detect cycles.
+type walk struct {
+	graph    *Graph
+	maxDepth int
+	path     map[string]struct{}
+
+	// negated counts the subtracted operands of Exclusion being checked.
+	negated int
+}
+
+// newWalk creates a traversal of graph.
+func (g *Graph) newWalk() *walk {
+	var maxDepth = g.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultGraphDepth
+	}
+	return &walk{graph: g, maxDepth: maxDepth, path: make(map[string]struct{})}
+}
+
+// enter adds the userset to the current path. It returns false if the userset
+// is already in the path.
+func (w *walk) enter(userset string) (bool, error) {
+	if _, ok := w.path[userset]; ok {
+		return false, nil
+	}
+	if len(w.path) >= w.maxDepth {
+		return false, ResolutionError.Newf("exceeded the max depth %d at %s", w.maxDepth, userset)
+	}
+	w.path[userset] = struct{}{}
+	return true, nil
+}
+
+// rule returns the rule of relation.
+func (w *walk) rule(relation Relation) Userset {
+	if rule, ok := w.graph.rules[relation]; ok {
+		return rule
+	}
+	return This()
+}
+
+// check returns true if subject has the relation over object in the context.
+// Cycles are considered as not having the relation, unless they are under the
+// subtracted operand of Exclusion, where "not having" would grant the access.
+// They are ResolutionErrors in this case.
+func (w *walk) check(context, object string, relation Relation, subject string) (bool, error) {
+	var userset = usersetString(context, object, relation)
+	if ok, err := w.enter(userset); err != nil {
+		return false, err
+	} else if !ok {
+		if w.negated > 0 {
+			return false, ResolutionError.Newf("cycle at %s under exclusion", userset)
+		}
+		return false, nil
+	}
+	defer delete(w.path, userset)
+
+	return w.checkUserset(w.rule(relation), context, object, relation, subject)
+}
+
+// checkUserset returns true if subject is in the userset of relation over
+// object in the context.
+func (w *walk) checkUserset(
+	u Userset, context, object string, relation Relation, subject string,
+) (bool, error) {
+	switch u.op {
+	case opThis:
+		var tuples, err = w.graph.Store.Read(Tuple{Context: context, Object: object, Relation: relation})
+		if err != nil {
+			return false, err
+		}
+		for _, t := range tuples {
+			if t.Subject == subject {
+				return true, nil
+			}
+			if c, o, r, ok := parseUserset(context, t.Subject); ok {
+				if ok, err := w.check(c, o, r, subject); ok || err != nil {
+					return ok, err
+				}
+			}
+		}
+		return false, nil
+
+	case opComputed:
+		return w.check(context, object, u.relation, subject)
+
+	case opTupleToUserset:
+		var tuples, err = w.graph.Store.Read(Tuple{Context: context, Object: object, Relation: u.tupleset})
+		if err != nil {
+			return false, err
+		}
+		for _, t := range tuples {
+			var c, o, _, _ = parseUserset(context, t.Subject)
+			if ok, err := w.check(c, o, u.relation, subject); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+
+	case opUnion:
+		for _, child := range u.children {
+			var ok, err = w.checkUserset(child, context, object, relation, subject)
+			if ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+
+	case opIntersection:
+		for _, child := range u.children {
+			var ok, err = w.checkUserset(child, context, object, relation, subject)
+			if !ok || err != nil {
+				return false, err
+			}
+		}
+		return len(u.children) > 0, nil
+
+	case opExclusion:
+		var ok, err = w.checkUserset(u.children[0], context, object, relation, subject)
+		if !ok || err != nil {
+			return false, err
+		}
+		w.negated++
+		ok, err = w.checkUserset(u.children[1], context, object, relation, subject)
+		w.negated--
+		return !ok && err == nil, err
+	}
+
+	panic(NotImplementedError.Newf("unknown userset operation %s", u.op))
+}
+
+// expandRelation returns the tree of relation over object in the context.
+func (w *walk) expandRelation(context, object string, relation Relation) (*ExpandNode, error) {
+	var userset = usersetString(context, object, relation)
+	if ok, err := w.enter(userset); err != nil {
+		return nil, err
+	} else if !ok {
+		return &ExpandNode{Operation: "cycle", Userset: userset}, nil
+	}
+	defer delete(w.path, userset)
+
+	return w.expandUserset(w.rule(relation), context, object, relation)
+}
+
+// expandUserset returns the tree of userset of relation over object in the
+// context.
+func (w *walk) expandUserset(u Userset, context, object string, relation Relation) (*ExpandNode, error) {
+	var node = &ExpandNode{Operation: u.op.String()}
+
+	switch u.op {
+	case opThis:
+		node.Userset = usersetString(context, object, relation)
+		var tuples, err = w.graph.Store.Read(Tuple{Context: context, Object: object, Relation: relation})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tuples {
+			var c, o, r, ok = parseUserset(context, t.Subject)
+			if !ok {
+				node.Subjects = append(node.Subjects, t.Subject)
+				continue
+			}
+			var child, err = w.expandRelation(c, o, r)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+
+	case opComputed:
+		node.Userset = usersetString(context, object, u.relation)
+		var child, err = w.expandRelation(context, object, u.relation)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+
+	case opTupleToUserset:
+		node.Userset = usersetString(context, object, u.tupleset)
+		var tuples, err = w.graph.Store.Read(Tuple{Context: context, Object: object, Relation: u.tupleset})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tuples {
+			var c, o, _, _ = parseUserset(context, t.Subject)
+			var child, err = w.expandRelation(c, o, u.relation)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+
+	default:
+		for _, child := range u.children {
+			var childNode, err = w.expandUserset(child, context, object, relation)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, childNode)
+		}
+	}
+
+	return node, nil
+}
+
+// usersetString returns the userset as "context/object#relation".
+func usersetString(context, object string, relation Relation) string {
+	var s = object + "#" + string(relation)
+	if context != "" {
+		s = context + "/" + s
+	}
+	return s
+}
+
+// parseUserset parses a subject set written as "context/object#relation", the
+// context is inherited from the tuple if it is omitted. If s isn't a subject
+// set, it is returned as the object and ok is false.
+func parseUserset(context, s string) (c, object string, relation Relation, ok bool) {
+	var hash = strings.LastIndex(s, "#")
+	if hash < 0 {
+		return context, s, "", false
+	}
+
+	c, object, relation = context, s[:hash], Relation(s[hash+1:])
+	if slash := strings.LastIndex(object, "/"); slash >= 0 {
+		c, object = object[:slash], object[slash+1:]
+	}
+	return c, object, relation, true
+}
@@ -0,0 +1,141 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"fmt"
+
+	"github.com/xybor-x/xypriv"
+)
+
+func ExampleGraph() {
+	var graph = xypriv.NewGraph(xypriv.NewMemoryStore())
+	graph.Rewrite("friendOfFriend", xypriv.Union(
+		xypriv.ComputedUserset("friend"),
+		xypriv.TupleToUserset("friend", "friend"),
+	))
+	graph.Rewrite("viewer", xypriv.Exclusion(
+		xypriv.ComputedUserset("member"),
+		xypriv.ComputedUserset("banned"),
+	))
+
+	for _, s := range []string{
+		"alice#friend@bob",
+		"bob#friend@carol",
+		"carol#friend@alice",
+		"group:admins#member@dave",
+		"group:staff#member@group:admins#member",
+		"group:staff#member@erin",
+		"group:staff#banned@erin",
+	} {
+		var t, _ = xypriv.ParseTuple(s)
+		graph.Write(t)
+	}
+
+	fmt.Println(graph.Check(xypriv.Tuple{Object: "alice", Relation: "friendOfFriend", Subject: "carol"}))
+	fmt.Println(graph.Check(xypriv.Tuple{Object: "alice", Relation: "friendOfFriend", Subject: "dave"}))
+	fmt.Println(graph.Relations("", "group:staff", "dave"))
+	fmt.Println(graph.Relations("", "group:staff", "erin"))
+
+	var tree, _ = graph.Expand("", "group:staff", "viewer")
+	fmt.Print(tree)
+
+	graph.MaxDepth = 1
+	fmt.Println(graph.Check(xypriv.Tuple{Object: "alice", Relation: "friendOfFriend", Subject: "carol"}))
+
+	// Output:
+	// true <nil>
+	// false <nil>
+	// [member viewer] <nil>
+	// [banned member] <nil>
+	// exclusion
+	//   computed group:staff#member
+	//     this group:staff#member [erin]
+	//       this group:admins#member [dave]
+	//   computed group:staff#banned
+	//     this group:staff#banned [erin]
+	// false ResolutionError: exceeded the max depth 1 at alice#friend
+}
+
+func ExampleGraph_storeSubject() {
+	var engine = xypriv.NewEngine()
+	engine.AddRelation(nil, "friend", xypriv.LowFamiliar)
+	engine.AddRelation(nil, "friendOfFriend", xypriv.Anyone)
+	engine.AddRelation(nil, "manager", xypriv.Moderator)
+
+	var graph = xypriv.NewGraph(xypriv.NewMemoryStore())
+	graph.Rewrite("manager", xypriv.Union(
+		xypriv.This(),
+		xypriv.TupleToUserset("manager", "manager"),
+	))
+	graph.Write(
+		xypriv.Tuple{Object: "alice", Relation: "manager", Subject: "bob"},
+		xypriv.Tuple{Object: "bob", Relation: "manager", Subject: "carol"},
+		xypriv.Tuple{Object: "carol", Relation: "manager", Subject: "alice"},
+	)
+
+	var post = storePost{author: xypriv.StoreSubject{ID: "alice", Store: graph, Engine: engine}}
+	var carol = xypriv.StoreSubject{ID: "carol", Store: graph, Engine: engine}
+	var d = engine.Check(carol).Perform("read").Decide(post)
+	fmt.Println(d.Relation, d.Allowed)
+
+	var tree, _ = graph.Expand("", "alice", "manager")
+	fmt.Print(tree)
+
+	// Output:
+	// manager true
+	// union
+	//   this alice#manager [bob]
+	//   tupleToUserset alice#manager
+	//     union
+	//       this bob#manager [carol]
+	//       tupleToUserset bob#manager
+	//         union
+	//           this carol#manager [alice]
+	//           tupleToUserset carol#manager
+	//             cycle alice#manager
+}
+
+func ExampleExclusion() {
+	var graph = xypriv.NewGraph(xypriv.NewMemoryStore())
+	graph.Rewrite("viewer", xypriv.Exclusion(
+		xypriv.ComputedUserset("member"),
+		xypriv.ComputedUserset("banned"),
+	))
+
+	// The banned lists of both groups include each other.
+	for _, s := range []string{
+		"group:a#member@frank",
+		"group:a#banned@group:b#banned",
+		"group:b#banned@group:a#banned",
+	} {
+		var t, _ = xypriv.ParseTuple(s)
+		graph.Write(t)
+	}
+
+	// A cycle under the subtracted operand can't prove frank isn't banned.
+	fmt.Println(graph.Check(xypriv.Tuple{Object: "group:a", Relation: "viewer", Subject: "frank"}))
+
+	// Output:
+	// false ResolutionError: cycle at group:a#banned under exclusion
+}
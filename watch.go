@@ -0,0 +1,512 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+// Change kinds.
+const (
+	// RelationAdded means that a tuple is written to a RelationStore.
+	RelationAdded ChangeKind = iota + 1
+
+	// RelationRemoved means that a tuple is deleted from a RelationStore.
+	RelationRemoved
+
+	// PrivilegeChanged means that a relation is added to an engine.
+	PrivilegeChanged
+
+	// PermissionChanged means that the permission of an abstract resource is
+	// set.
+	PermissionChanged
+
+	// TokenRevoked means that a token is revoked.
+	TokenRevoked
+
+	// ResourceChanged means that the context, the owner, or the labels of an
+	// abstract resource are set. Followers refer to the context by its name
+	// and to the owner by a SubjectRef.
+	ResourceChanged
+
+	// TokenChanged means that a token is created, bound to a subject, or its
+	// rules are set.
+	TokenChanged
+
+	// ParentChanged means that the parent of a context is registered or
+	// removed.
+	ParentChanged
+
+	// MappingAdded means that a relation mapping rule is added to an engine.
+	MappingAdded
+
+	// LatticeChanged means that the lattice of a context is set or removed.
+	LatticeChanged
+
+	// MACChanged means that the MAC modes of an engine are set.
+	MACChanged
+
+	// ActionModeChanged means that an action is tagged as reading or writing.
+	ActionModeChanged
+)
+
+// String returns the name of kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case RelationAdded:
+		return "RelationAdded"
+	case RelationRemoved:
+		return "RelationRemoved"
+	case PrivilegeChanged:
+		return "PrivilegeChanged"
+	case PermissionChanged:
+		return "PermissionChanged"
+	case TokenRevoked:
+		return "TokenRevoked"
+	case ResourceChanged:
+		return "ResourceChanged"
+	case TokenChanged:
+		return "TokenChanged"
+	case ParentChanged:
+		return "ParentChanged"
+	case MappingAdded:
+		return "MappingAdded"
+	case LatticeChanged:
+		return "LatticeChanged"
+	case MACChanged:
+		return "MACChanged"
+	case ActionModeChanged:
+		return "ActionModeChanged"
+	}
+	return "Unknown"
+}
+
+// Change is an entry of the change feed. Only the fields of its kind are set.
+type Change struct {
+	// Revision is assigned by the Broadcaster, it increases monotonically
+	// from 1.
+	Revision uint64 `json:"revision"`
+
+	Kind ChangeKind `json:"kind"`
+	Time time.Time  `json:"time"`
+
+	// Origin identifies the engine or store publishing the change, so that it
+	// doesn't apply its own changes again.
+	Origin string `json:"origin,omitempty"`
+
+	// Tuple is set for RelationAdded and RelationRemoved.
+	Tuple Tuple `json:"tuple"`
+
+	// Context, Relation, and Privilege are set for PrivilegeChanged. Context
+	// is also set for ResourceChanged.
+	Context   string    `json:"context,omitempty"`
+	Relation  Relation  `json:"relation,omitempty"`
+	Privilege Privilege `json:"privilege,omitempty"`
+
+	// Resource, Action, and AccessLevel are set for PermissionChanged.
+	// Resource is also set for ResourceChanged, Action is also set for
+	// ActionModeChanged.
+	Resource    string      `json:"resource,omitempty"`
+	Action      string      `json:"action,omitempty"`
+	AccessLevel AccessLevel `json:"access_level,omitempty"`
+
+	// Owner, Classification, and Integrity are set for ResourceChanged.
+	// Owner is the ID of owner.
+	Owner          string `json:"owner,omitempty"`
+	Classification *Label `json:"classification,omitempty"`
+	Integrity      *Label `json:"integrity,omitempty"`
+
+	// Token is set for TokenRevoked and TokenChanged.
+	Token string `json:"token,omitempty"`
+
+	// Subject and Rules are set for TokenChanged. Subject is the ID of bound
+	// subject, Rules are all rules of token.
+	Subject string          `json:"subject,omitempty"`
+	Rules   map[string]bool `json:"rules,omitempty"`
+
+	// Parent is set for ParentChanged, it is the name of parent of Context.
+	// An empty parent means that the parent is removed.
+	Parent string `json:"parent,omitempty"`
+
+	// Mapping is set for MappingAdded, its contexts are names.
+	Mapping *RelationMapping `json:"mapping,omitempty"`
+
+	// Lattice is set for LatticeChanged, it is the lattice of Context or nil
+	// if the lattice is removed. Lattices are objects, so they are only
+	// delivered by in-process broadcasters.
+	Lattice Lattice `json:"-"`
+
+	// MAC is set for MACChanged, Mode is set for ActionModeChanged.
+	MAC  MACMode    `json:"mac,omitempty"`
+	Mode AccessMode `json:"mode,omitempty"`
+}
+
+// Broadcaster instances order changes and deliver them to watchers.
+type Broadcaster interface {
+	// Publish assigns the next revision to the change, then delivers it to
+	// watchers. It is called synchronously, so it should return quickly.
+	Publish(c Change) Change
+
+	// Watch returns the channel of changes whose revisions are not less than
+	// fromRevision, in order. The channel is closed after the returned
+	// function is called.
+	Watch(fromRevision uint64) (<-chan Change, func())
+
+	// ID returns the identity of broadcaster. Revisions of different
+	// broadcasters are not comparable.
+	ID() string
+}
+
+// broadcasterCounter generates the IDs of MemoryBroadcasters.
+var broadcasterCounter uint64
+
+// MemoryBroadcaster is an in-process Broadcaster keeping all changes in
+// memory.
+type MemoryBroadcaster struct {
+	id      string
+	mu      sync.Mutex
+	changes []Change
+	notify  chan struct{}
+}
+
+// NewMemoryBroadcaster creates an empty MemoryBroadcaster.
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{
+		id:     fmt.Sprintf("broadcaster#%d", atomic.AddUint64(&broadcasterCounter, 1)),
+		notify: make(chan struct{}),
+	}
+}
+
+// ID implements Broadcaster interface.
+func (b *MemoryBroadcaster) ID() string {
+	return b.id
+}
+
+// Publish implements Broadcaster interface.
+func (b *MemoryBroadcaster) Publish(c Change) Change {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c.Revision = uint64(len(b.changes)) + 1
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+	b.changes = append(b.changes, c)
+
+	close(b.notify)
+	b.notify = make(chan struct{})
+	return c
+}
+
+// Revision returns the revision of the latest change.
+func (b *MemoryBroadcaster) Revision() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return uint64(len(b.changes))
+}
+
+// Watch implements Broadcaster interface.
+func (b *MemoryBroadcaster) Watch(fromRevision uint64) (<-chan Change, func()) {
+	var changes = make(chan Change)
+	var done = make(chan struct{})
+	var next = fromRevision
+	if next == 0 {
+		next = 1
+	}
+
+	go func() {
+		defer close(changes)
+		for {
+			b.mu.Lock()
+			var pending []Change
+			if next <= uint64(len(b.changes)) {
+				pending = b.changes[next-1:]
+			}
+			var notify = b.notify
+			b.mu.Unlock()
+
+			for _, c := range pending {
+				select {
+				case changes <- c:
+					next = c.Revision + 1
+				case <-done:
+					return
+				}
+			}
+
+			if len(pending) == 0 {
+				select {
+				case <-notify:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return changes, func() { once.Do(func() { close(done) }) }
+}
+
+// Revocable instances are Delegatees which can be revoked by their IDs.
+type Revocable interface {
+	// TokenID returns the identity of Delegatee.
+	TokenID() string
+}
+
+// SetBroadcaster sets the broadcaster of the default engine.
+func SetBroadcaster(b Broadcaster) {
+	defaultEngine.SetBroadcaster(b)
+}
+
+// SetBroadcaster publishes the changes of engine to b. They are added
+// relations, abstract resources, and revoked tokens. Pass nil to stop
+// publishing.
+func (e *Engine) SetBroadcaster(b Broadcaster) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.broadcaster = b
+}
+
+// Follow applies changes of b whose revisions are not less than fromRevision
+// to the engine in the background, changes published by the engine itself are
+// skipped. Changes of relation stores purge the engine cache. It returns the
+// function stopping the following.
+func (e *Engine) Follow(b Broadcaster, fromRevision uint64) func() {
+	e.mu.Lock()
+	e.followed = b
+	e.mu.Unlock()
+
+	var changes, stop = b.Watch(fromRevision)
+	go func() {
+		for c := range changes {
+			e.apply(c)
+		}
+	}()
+
+	return func() {
+		stop()
+		e.mu.Lock()
+		e.followed = nil
+		e.mu.Unlock()
+	}
+}
+
+// Revision returns the revision of the latest change published or applied by
+// the engine.
+func (e *Engine) Revision() uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.revision
+}
+
+// WaitRevision blocks until the revision of engine reaches rev or ctx is done.
+func (e *Engine) WaitRevision(ctx context.Context, rev uint64) error {
+	for {
+		e.mu.RLock()
+		var revision, advanced = e.revision, e.advanced
+		e.mu.RUnlock()
+
+		if revision >= rev {
+			return nil
+		}
+
+		select {
+		case <-advanced:
+		case <-ctx.Done():
+			return canceled(ctx.Err())
+		}
+	}
+}
+
+// RevokeToken revokes the token having the ID in the default engine.
+func RevokeToken(id string) {
+	defaultEngine.RevokeToken(id)
+}
+
+// RevokeToken revokes the Revocable delegatee having the ID, so that all
+// checks delegated to it are denied.
+func (e *Engine) RevokeToken(id string) {
+	e.revokeToken(id)
+	if t, ok := e.FindToken(id); ok {
+		e.persistToken(t)
+	} else {
+		e.persist(func(s Store) error { return s.SaveToken(TokenRecord{ID: id, Revoked: true}) })
+	}
+	e.publish(Change{Kind: TokenRevoked, Token: id})
+}
+
+// revokeToken records the revoked token.
+func (e *Engine) revokeToken(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.revoked[id] = struct{}{}
+}
+
+// isRevoked returns true if the token having the ID is revoked.
+func (e *Engine) isRevoked(id string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	_, ok := e.revoked[id]
+	return ok
+}
+
+// publish sends the change to the broadcaster of engine if any.
+func (e *Engine) publish(c Change) {
+	e.mu.RLock()
+	var b = e.broadcaster
+	e.mu.RUnlock()
+
+	if b == nil {
+		return
+	}
+
+	c.Origin = e.origin
+	e.advance(b.Publish(c).Revision)
+}
+
+// apply applies the change published by another engine or store.
+func (e *Engine) apply(c Change) {
+	if c.Origin != e.origin {
+		switch c.Kind {
+		case RelationAdded, RelationRemoved:
+			if cache := e.getCache(); cache != nil {
+				cache.Purge()
+			}
+		case PrivilegeChanged:
+			e.addRelation(c.Context, c.Relation, c.Privilege)
+		case PermissionChanged:
+			var r = e.AbstractResource(c.Resource)
+			r.setPermission(c.AccessLevel, c.Action)
+		case ResourceChanged:
+			e.applyResource(c)
+		case TokenRevoked:
+			e.revokeToken(c.Token)
+		case TokenChanged:
+			// A token having an invalid ID can't be restored, it is ignored.
+			e.loadToken(TokenRecord{ID: c.Token, Subject: c.Subject, Rules: c.Rules})
+		case ParentChanged:
+			e.setContextParent(c.Context, policyContext(c.Parent))
+		case MappingAdded:
+			e.addRelationMapping(*c.Mapping)
+		case LatticeChanged:
+			e.setLattice(c.Context, c.Lattice)
+		case MACChanged:
+			e.setMAC(c.MAC)
+		case ActionModeChanged:
+			e.setActionMode(c.Mode, c.Action)
+		}
+	}
+
+	e.advance(c.Revision)
+}
+
+// applyResource sets the context, the owner, and the labels of abstract
+// resource given by a ResourceChanged change.
+func (e *Engine) applyResource(c Change) {
+	e.AbstractResource(c.Resource)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var r = e.resources[c.Resource]
+	r.context = policyContext(c.Context)
+	r.owner = nil
+	if c.Owner != "" {
+		r.owner = SubjectRef{ID: c.Owner}
+	}
+	r.classification, r.integrity = Label{}, Label{}
+	if c.Classification != nil {
+		r.classification = *c.Classification
+	}
+	if c.Integrity != nil {
+		r.integrity = *c.Integrity
+	}
+	e.resources[c.Resource] = r
+	e.policyChanged()
+}
+
+// advance moves the revision of engine forward to rev.
+func (e *Engine) advance(rev uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if rev > e.revision {
+		e.revision = rev
+		close(e.advanced)
+		e.advanced = make(chan struct{})
+	}
+}
+
+// BroadcastStore is a RelationStore publishing written and deleted tuples to a
+// Broadcaster.
+type BroadcastStore struct {
+	// Store contains the tuples.
+	Store RelationStore
+
+	// Broadcaster receives the changes.
+	Broadcaster Broadcaster
+
+	// Origin identifies the store in changes.
+	Origin string
+}
+
+// Write adds tuples to the store, then publishes them.
+func (s BroadcastStore) Write(tuples ...Tuple) error {
+	if err := s.Store.Write(tuples...); err != nil {
+		return err
+	}
+	s.publish(RelationAdded, tuples)
+	return nil
+}
+
+// Delete removes tuples from the store, then publishes them.
+func (s BroadcastStore) Delete(tuples ...Tuple) error {
+	if err := s.Store.Delete(tuples...); err != nil {
+		return err
+	}
+	s.publish(RelationRemoved, tuples)
+	return nil
+}
+
+// Read returns tuples of the store matching filter.
+func (s BroadcastStore) Read(filter Tuple) ([]Tuple, error) {
+	return s.Store.Read(filter)
+}
+
+// publish publishes a change of kind for every tuple, then returns the
+// revision of the last change.
+func (s BroadcastStore) publish(kind ChangeKind, tuples []Tuple) uint64 {
+	var rev uint64
+	for _, t := range tuples {
+		rev = s.Broadcaster.Publish(Change{Kind: kind, Origin: s.Origin, Tuple: t}).Revision
+	}
+	return rev
+}
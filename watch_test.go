@@ -0,0 +1,241 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xypriv_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// watchEditor implements Subject interface.
+type watchEditor struct{}
+
+// Relation returns "editor" in any context.
+func (watchEditor) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	return "editor"
+}
+
+func ExampleEngine_Follow() {
+	var broadcaster = xypriv.NewMemoryBroadcaster()
+
+	var leader = xypriv.NewEngine()
+	leader.SetBroadcaster(broadcaster)
+
+	var follower = xypriv.NewEngine()
+	var stop = follower.Follow(broadcaster, 0)
+	defer stop()
+
+	leader.AddRelation(nil, "editor", xypriv.Moderator)
+	var article = leader.AbstractResource("article")
+	article.SetPermission(xypriv.HighConfidential, "update")
+
+	var token = leader.NewToken()
+	token.AllowAction("update")
+
+	follower.WaitRevision(context.Background(), leader.Revision())
+	var resource, _ = follower.FindAbstractResource("article")
+	fmt.Println(follower.Check(watchEditor{}).Delegate(token).Perform("update").On(resource))
+
+	token.Revoke()
+	follower.WaitRevision(context.Background(), leader.Revision())
+	var d = follower.Check(watchEditor{}).Delegate(token).Perform("update").Decide(resource)
+	fmt.Println(follower.Revision(), d.Allowed)
+
+	var changes, cancel = broadcaster.Watch(2)
+	for i := 0; i < 4; i++ {
+		var c = <-changes
+		fmt.Println(c.Revision, c.Kind, c.Resource, c.Action, c.AccessLevel)
+	}
+	cancel()
+
+	// Output:
+	// <nil>
+	// 5 false
+	// 2 PermissionChanged article update 7
+	// 3 TokenChanged   0
+	// 4 TokenChanged   0
+	// 5 TokenRevoked   0
+}
+
+// watchMember implements Subject interface, it has a relation per context.
+type watchMember struct {
+	relations map[string]xypriv.Relation
+}
+
+// Relation returns the relation of member in the context, or "anyone".
+func (m watchMember) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	if r, ok := m.relations[fmt.Sprint(ctx)]; ok {
+		return r
+	}
+	return "anyone"
+}
+
+func ExampleEngine_Follow_hierarchy() {
+	var broadcaster = xypriv.NewMemoryBroadcaster()
+
+	var leader = xypriv.NewEngine()
+	leader.SetBroadcaster(broadcaster)
+
+	var follower = xypriv.NewEngine()
+	var stop = follower.Follow(broadcaster, 0)
+	defer stop()
+
+	leader.AddRelation("org", "orgAdmin", xypriv.Admin)
+	leader.AddRelation("team", "teamMember", xypriv.LowFamiliar)
+	leader.SetContextParent("team", "org")
+	leader.AddRelationMapping(xypriv.RelationMapping{
+		From:      "org",
+		Relation:  "orgAdmin",
+		To:        "team",
+		Privilege: xypriv.LocalAdmin,
+	})
+
+	follower.WaitRevision(context.Background(), leader.Revision())
+	var admin = watchMember{relations: map[string]xypriv.Relation{
+		"org":  "orgAdmin",
+		"team": "teamMember",
+	}}
+	var d = follower.Check(admin).Perform("delete").Decide(hierarchyDoc{context: "team"})
+	fmt.Println(d.Privilege, d.Allowed, d.Explanation)
+
+	// Removing the parent stops the mapping on followers too.
+	leader.SetContextParent("team", nil)
+	follower.WaitRevision(context.Background(), leader.Revision())
+	d = follower.Check(admin).Perform("delete").Decide(hierarchyDoc{context: "team"})
+	fmt.Println(d.Privilege, d.Allowed)
+
+	// Output:
+	// 8 true [relation orgadmin in context org maps to privilege 8 in context team]
+	// 2 false
+}
+
+func ExampleEngine_Follow_resource() {
+	var broadcaster = xypriv.NewMemoryBroadcaster()
+
+	var leader = xypriv.NewEngine()
+	leader.SetBroadcaster(broadcaster)
+
+	var follower = xypriv.NewEngine()
+	var stop = follower.Follow(broadcaster, 0)
+	defer stop()
+
+	leader.AddRelation("team", "teamMember", xypriv.LowFamiliar)
+	var doc = leader.AbstractResource("doc")
+	doc.SetContext("team")
+	doc.SetOwner(xypriv.SubjectRef{ID: "bob"})
+	doc.SetPermission(xypriv.LowPrivate, "read")
+	doc.SetPermission(xypriv.HighPrivate, "update")
+
+	// The follower knows the context by name and the owner by ID.
+	follower.WaitRevision(context.Background(), leader.Revision())
+	var resource, _ = follower.FindAbstractResource("doc")
+	fmt.Println(resource.Context(), resource.Owner())
+
+	var member = watchMember{relations: map[string]xypriv.Relation{"team": "teamMember"}}
+	var d = follower.Check(member).Perform("read").Decide(resource)
+	fmt.Println(d.Relation, d.Allowed)
+
+	d = follower.Check(xypriv.SubjectRef{ID: "bob"}).Perform("update").Decide(resource)
+	fmt.Println(d.Relation, d.Allowed)
+
+	// Output:
+	// team {bob}
+	// teamMember true
+	// self true
+}
+
+func ExampleEngine_Follow_lattice() {
+	var broadcaster = xypriv.NewMemoryBroadcaster()
+
+	var leader = xypriv.NewEngine()
+	leader.SetBroadcaster(broadcaster)
+	leader.SetHistory(broadcaster)
+
+	var follower = xypriv.NewEngine()
+	var stop = follower.Follow(broadcaster, 0)
+	defer stop()
+
+	leader.AddRelation("vault", "auditor", xypriv.LowFamiliar)
+	var ledger = leader.AbstractResource("ledger")
+	ledger.SetContext("vault")
+	ledger.SetPermission(xypriv.LowPrivate, "read")
+
+	time.Sleep(time.Millisecond)
+	var monday = time.Now()
+	time.Sleep(time.Millisecond)
+
+	// Unmapped privileges never dominate in an empty MLSLattice.
+	leader.SetLattice("vault", xypriv.NewMLSLattice())
+
+	var auditor = watchMember{relations: map[string]xypriv.Relation{"vault": "auditor"}}
+	follower.WaitRevision(context.Background(), leader.Revision())
+	var resource, _ = follower.FindAbstractResource("ledger")
+	fmt.Println(follower.Check(auditor).Perform("read").Decide(resource).Allowed)
+	fmt.Println(leader.Check(auditor).Perform("read").AsOf(monday).Decide(ledger).Allowed)
+
+	leader.SetLattice("vault", nil)
+	follower.WaitRevision(context.Background(), leader.Revision())
+	fmt.Println(follower.Check(auditor).Perform("read").Decide(resource).Allowed)
+
+	// Output:
+	// false
+	// true
+	// true
+}
+
+func ExampleEngine_Follow_mac() {
+	var broadcaster = xypriv.NewMemoryBroadcaster()
+
+	var leader = xypriv.NewEngine()
+	leader.SetBroadcaster(broadcaster)
+	leader.SetHistory(broadcaster)
+
+	var follower = xypriv.NewEngine()
+	var stop = follower.Follow(broadcaster, 0)
+	defer stop()
+
+	leader.AddRelation(nil, "operator", xypriv.LowFamiliar)
+	var report = leader.AbstractResource("report")
+	report.SetPermission(xypriv.LowPrivate, "read")
+	report.SetClassification(xypriv.Label{Level: 2})
+
+	time.Sleep(time.Millisecond)
+	var monday = time.Now()
+	time.Sleep(time.Millisecond)
+
+	leader.SetMAC(xypriv.BellLaPadula)
+	leader.SetActionMode(xypriv.Read, "read")
+
+	var job = pipelineJob{name: "etl", clearance: 1}
+	follower.WaitRevision(context.Background(), leader.Revision())
+	var resource, _ = follower.FindAbstractResource("report")
+	fmt.Println(follower.Check(job).Perform("read").On(resource))
+	fmt.Println(leader.Check(job).Perform("read").AsOf(monday).On(report))
+
+	// Output:
+	// MACError: etl can't read up report classified as 2{}
+	// <nil>
+}
@@ -0,0 +1,170 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package xyprivhttp provides net/http middleware enforcing xypriv checks.
+package xyprivhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// contextKey is the type of keys stored in request context.
+type contextKey int
+
+const checkerKey contextKey = iota
+
+// Config configures the middleware.
+type Config struct {
+	// Engine evaluates the checks. The default engine is used if it is nil.
+	Engine *xypriv.Engine
+
+	// Subject extracts the subject from request. A nil subject is considered
+	// as anonymous. Return an UnauthenticatedError to respond 401.
+	Subject func(r *http.Request) (xypriv.Subject, error)
+
+	// Resource loads the resource of request. Return a ResourceError to
+	// respond 404.
+	Resource func(r *http.Request) (xypriv.Resource, error)
+
+	// Action maps the request to an action, for example from the method and
+	// the route.
+	Action func(r *http.Request) []string
+
+	// Delegatee optionally extracts the delegatee of request, such as a
+	// token. No delegatee is used if it is nil or returns nil.
+	Delegatee func(r *http.Request) (xypriv.Delegatee, error)
+
+	// ErrorHandler renders the error response. By default, it writes the
+	// status text.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, status int, err error)
+}
+
+// Middleware returns a middleware which checks if the subject of request can
+// perform the action on resource. If the check passes, the subject and the
+// Checker are stored in the request context before calling the next handler.
+// Otherwise, it responds:
+//   - 401 if the subject can't be extracted, or an anonymous subject is
+//     denied.
+//   - 403 if the subject is denied.
+//   - 404 if the resource can't be loaded with a ResourceError.
+//   - 503 if the request context is done during the check.
+//   - 500 for other errors.
+func Middleware(cfg Config) func(http.Handler) http.Handler {
+	if cfg.Subject == nil || cfg.Resource == nil || cfg.Action == nil {
+		panic(xypriv.ConfigurationError.New(
+			"xyprivhttp requires the subject extractor, resource loader, and action mapper"))
+	}
+
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = DefaultErrorHandler
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var subject, err = cfg.Subject(r)
+			if err != nil {
+				cfg.ErrorHandler(w, r, Status(err), err)
+				return
+			}
+
+			resource, err := cfg.Resource(r)
+			if err != nil {
+				cfg.ErrorHandler(w, r, Status(err), err)
+				return
+			}
+
+			var ctx = xypriv.WithSubject(r.Context(), subject)
+			if cfg.Delegatee != nil {
+				var delegatee, err = cfg.Delegatee(r)
+				if err != nil {
+					cfg.ErrorHandler(w, r, Status(err), err)
+					return
+				}
+				if delegatee != nil {
+					ctx = xypriv.WithToken(ctx, delegatee)
+				}
+			}
+
+			var checker = cfg.check(ctx)
+			if err := checker.Perform(cfg.Action(r)...).OnContext(ctx, resource); err != nil {
+				var status = Status(err)
+				if subject == nil && status == http.StatusForbidden {
+					status = http.StatusUnauthorized
+				}
+				cfg.ErrorHandler(w, r, status, err)
+				return
+			}
+
+			ctx = context.WithValue(ctx, checkerKey, checker)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// check creates a Checker of the configured engine with the subject and token
+// carried by ctx.
+func (cfg Config) check(ctx context.Context) *xypriv.Checker {
+	if cfg.Engine != nil {
+		return cfg.Engine.CheckContext(ctx)
+	}
+	return xypriv.CheckContext(ctx)
+}
+
+// Status returns the HTTP status code corresponding to the error.
+func Status(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, xypriv.UnauthenticatedError):
+		return http.StatusUnauthorized
+	case errors.Is(err, xypriv.PermissionError):
+		return http.StatusForbidden
+	case errors.Is(err, xypriv.ResourceError):
+		return http.StatusNotFound
+	case errors.Is(err, xypriv.CanceledError):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// DefaultErrorHandler writes the status text as the response.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, status int, err error) {
+	http.Error(w, http.StatusText(status), status)
+}
+
+// SubjectFrom returns the subject stored by the middleware. It is the same as
+// xypriv.SubjectFromContext.
+func SubjectFrom(ctx context.Context) xypriv.Subject {
+	return xypriv.SubjectFromContext(ctx)
+}
+
+// CheckerFrom returns the Checker stored by the middleware, it can be used to
+// perform further checks with the same subject and delegatee.
+func CheckerFrom(ctx context.Context) *xypriv.Checker {
+	var c, _ = ctx.Value(checkerKey).(*xypriv.Checker)
+	return c
+}
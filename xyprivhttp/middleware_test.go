@@ -0,0 +1,115 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xyprivhttp_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/xybor-x/xypriv"
+	"github.com/xybor-x/xypriv/xyprivhttp"
+)
+
+// User implements Subject interface.
+type User struct {
+	id   string
+	role string
+}
+
+// Relation returns the role of user as its relation.
+func (u User) Relation(ctx any, subject xypriv.Subject) xypriv.Relation {
+	if u.role == "" {
+		return "anyone"
+	}
+	return xypriv.Relation(u.role)
+}
+
+var users = map[string]User{
+	"admin-token": {id: "admin", role: "admin"},
+	"user-token":  {id: "user"},
+}
+
+func Example() {
+	var engine = xypriv.NewEngine()
+	var table = engine.AbstractResource("account_table")
+	table.SetPermission(xypriv.HighSecret, "delete")
+	table.SetPermission(xypriv.Public, "get")
+
+	var middleware = xyprivhttp.Middleware(xyprivhttp.Config{
+		Engine: engine,
+		Subject: func(r *http.Request) (xypriv.Subject, error) {
+			var auth = r.Header.Get("Authorization")
+			if auth == "" {
+				return nil, nil
+			}
+			if u, ok := users[auth]; ok {
+				return u, nil
+			}
+			return nil, xypriv.UnauthenticatedError.New("invalid token")
+		},
+		Resource: func(r *http.Request) (xypriv.Resource, error) {
+			if r.URL.Path != "/accounts" {
+				return nil, xypriv.ResourceError.Newf("%s not found", r.URL.Path)
+			}
+			return table, nil
+		},
+		Action: func(r *http.Request) []string {
+			return []string{strings.ToLower(r.Method)}
+		},
+	})
+
+	var handler = middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var u, _ = xyprivhttp.SubjectFrom(r.Context()).(User)
+		fmt.Fprintf(w, "hello %q", u.id)
+	}))
+
+	var requests = []struct{ method, path, auth string }{
+		{http.MethodGet, "/accounts", ""},
+		{http.MethodDelete, "/accounts", ""},
+		{http.MethodDelete, "/accounts", "user-token"},
+		{http.MethodDelete, "/accounts", "admin-token"},
+		{http.MethodGet, "/accounts", "bad-token"},
+		{http.MethodGet, "/posts", "user-token"},
+	}
+
+	for _, req := range requests {
+		var r = httptest.NewRequest(req.method, req.path, nil)
+		if req.auth != "" {
+			r.Header.Set("Authorization", req.auth)
+		}
+
+		var w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		fmt.Println(req.method, req.path, req.auth, w.Code, strings.TrimSpace(w.Body.String()))
+	}
+
+	// Output:
+	// GET /accounts  200 hello ""
+	// DELETE /accounts  401 Unauthorized
+	// DELETE /accounts user-token 403 Forbidden
+	// DELETE /accounts admin-token 200 hello "admin"
+	// GET /accounts bad-token 401 Unauthorized
+	// GET /posts user-token 404 Not Found
+}
@@ -0,0 +1,289 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xyprivhttp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/xybor-x/xypriv"
+)
+
+// Route maps an endpoint to an abstract resource and an action.
+type Route struct {
+	// Method is the HTTP method of route, an empty method matches all
+	// methods.
+	Method string `json:"method"`
+
+	// Pattern is the path of route. A segment like {name} matches any
+	// non-empty segment and is captured as a path parameter.
+	Pattern string `json:"pattern"`
+
+	// Resource is the name of abstract resource.
+	Resource string `json:"resource"`
+
+	// Action is the action tuple performed on resource. An element like
+	// {name} is replaced by the path parameter.
+	Action []string `json:"action"`
+}
+
+// compiledRoute is a Route whose pattern is split into segments.
+type compiledRoute struct {
+	Route
+	segments []string
+}
+
+// RouteTable maps requests to abstract resources and actions. It doesn't
+// depend on any router.
+type RouteTable struct {
+	engine *xypriv.Engine
+	routes []compiledRoute
+}
+
+// NewRouteTable creates a RouteTable whose abstract resources belong to the
+// engine. The default engine is used if engine is nil.
+func NewRouteTable(engine *xypriv.Engine, routes ...Route) *RouteTable {
+	var t = &RouteTable{engine: engine}
+	for _, r := range routes {
+		t.Add(r)
+	}
+	return t
+}
+
+// Add adds a route to the table. Routes are matched in the order they are
+// added.
+func (t *RouteTable) Add(r Route) {
+	t.routes = append(t.routes, compiledRoute{
+		Route:    r,
+		segments: splitPath(r.Pattern),
+	})
+}
+
+// Routes returns all routes in the table.
+func (t *RouteTable) Routes() []Route {
+	var routes = make([]Route, len(t.routes))
+	for i := range t.routes {
+		routes[i] = t.routes[i].Route
+	}
+	return routes
+}
+
+// Match returns the first route matching the method and path, and its path
+// parameters.
+func (t *RouteTable) Match(method, path string) (Route, map[string]string, bool) {
+	var segments = splitPath(path)
+	for _, r := range t.routes {
+		if r.Method != "" && r.Method != method {
+			continue
+		}
+
+		if params, ok := r.match(segments); ok {
+			return r.Route, params, true
+		}
+	}
+	return Route{}, nil, false
+}
+
+// Resource is a resource loader of Config. It returns the abstract resource
+// of the matched route, or a ResourceError if no route matches or the abstract
+// resource doesn't exist. Requests never create abstract resources.
+func (t *RouteTable) Resource(r *http.Request) (xypriv.Resource, error) {
+	var route, _, ok = t.Match(r.Method, r.URL.Path)
+	if !ok {
+		return nil, xypriv.ResourceError.Newf("no route for %s %s", r.Method, r.URL.Path)
+	}
+
+	var resource xypriv.AbstractResourceDetails
+	if t.engine != nil {
+		resource, ok = t.engine.FindAbstractResource(route.Resource)
+	} else {
+		resource, ok = xypriv.FindAbstractResource(route.Resource)
+	}
+	if !ok {
+		return nil, xypriv.ResourceError.Newf("unknown resource %s of route %s %s",
+			route.Resource, r.Method, r.URL.Path)
+	}
+	return resource, nil
+}
+
+// Action is an action mapper of Config. It returns the action of the matched
+// route whose parameters are replaced by path parameters.
+func (t *RouteTable) Action(r *http.Request) []string {
+	var route, params, ok = t.Match(r.Method, r.URL.Path)
+	if !ok {
+		return nil
+	}
+
+	var action = make([]string, len(route.Action))
+	for i, a := range route.Action {
+		if name, ok := paramName(a); ok {
+			a = params[name]
+		}
+		action[i] = a
+	}
+	return action
+}
+
+// Middleware returns the middleware checking requests against the table.
+func (t *RouteTable) Middleware(subject func(r *http.Request) (xypriv.Subject, error)) func(http.Handler) http.Handler {
+	return Middleware(Config{
+		Engine:   t.engine,
+		Subject:  subject,
+		Resource: t.Resource,
+		Action:   t.Action,
+	})
+}
+
+// Requirement is the access level required by a route. An action with path
+// parameters may have many access levels.
+type Requirement struct {
+	Method   string             `json:"method"`
+	Pattern  string             `json:"pattern"`
+	Resource string             `json:"resource"`
+	Action   string             `json:"action"`
+	Level    xypriv.AccessLevel `json:"level"`
+}
+
+// Requirements returns the access levels required by all routes. Actions with
+// path parameters are expanded to all permissions of the resource matching
+// them. Actions without any permission are reported as NotSupport.
+func (t *RouteTable) Requirements() []Requirement {
+	var policy *xypriv.Policy
+	if t.engine != nil {
+		policy = t.engine.Policy()
+	} else {
+		policy = xypriv.CurrentPolicy()
+	}
+
+	var result []Requirement
+	for _, r := range t.routes {
+		var permissions = policy.Resources[r.Resource].Permissions
+		var matcher = actionMatcher(r.Action)
+
+		var actions []string
+		for action := range permissions {
+			if matcher.MatchString(action) {
+				actions = append(actions, action)
+			}
+		}
+		sort.Strings(actions)
+
+		if len(actions) == 0 {
+			result = append(result, Requirement{
+				Method:   r.Method,
+				Pattern:  r.Pattern,
+				Resource: r.Resource,
+				Action:   strings.Join(r.Action, "_"),
+				Level:    xypriv.NotSupport,
+			})
+		}
+
+		for _, action := range actions {
+			result = append(result, Requirement{
+				Method:   r.Method,
+				Pattern:  r.Pattern,
+				Resource: r.Resource,
+				Action:   action,
+				Level:    permissions[action],
+			})
+		}
+	}
+
+	return result
+}
+
+// WriteRequirements writes the table of all routes with their required access
+// levels to w.
+func (t *RouteTable) WriteRequirements(w io.Writer) error {
+	var tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tRESOURCE\tACTION\tLEVEL")
+	for _, r := range t.Requirements() {
+		var method = r.Method
+		if method == "" {
+			method = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", method, r.Pattern, r.Resource, r.Action, r.Level)
+	}
+	return tw.Flush()
+}
+
+// match returns the path parameters if segments match the route.
+func (r compiledRoute) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+
+	var params = map[string]string{}
+	for i, s := range r.segments {
+		if name, ok := paramName(s); ok {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[name] = segments[i]
+			continue
+		}
+
+		if s != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// splitPath splits the path into segments, ignoring the leading and trailing
+// slashes.
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// paramName returns the parameter name if s looks like {name}.
+func paramName(s string) (string, bool) {
+	if len(s) > 2 && s[0] == '{' && s[len(s)-1] == '}' {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+// actionMatcher returns the regular expression matching the joined actions
+// which the action template can produce.
+func actionMatcher(action []string) *regexp.Regexp {
+	var parts = make([]string, len(action))
+	for i, a := range action {
+		if _, ok := paramName(a); ok {
+			parts[i] = "[^_]+"
+		} else {
+			parts[i] = regexp.QuoteMeta(a)
+		}
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, "_") + "$")
+}
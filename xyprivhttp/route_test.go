@@ -0,0 +1,107 @@
+// MIT License
+//
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xyprivhttp_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/xybor-x/xypriv"
+	"github.com/xybor-x/xypriv/xyprivhttp"
+)
+
+func ExampleRouteTable() {
+	var engine = xypriv.NewEngine()
+	var table = engine.AbstractResource("account_table")
+	table.SetPermission(xypriv.HighSecret, "create", "admin")
+	table.SetPermission(xypriv.HighConfidential, "create", "mod")
+	table.SetPermission(xypriv.Public, "create", "user")
+	table.SetPermission(xypriv.Public, "list")
+
+	var routes = xyprivhttp.NewRouteTable(engine,
+		xyprivhttp.Route{
+			Method:   http.MethodPost,
+			Pattern:  "/accounts/{role}",
+			Resource: "account_table",
+			Action:   []string{"create", "{role}"},
+		},
+		xyprivhttp.Route{
+			Method:   http.MethodGet,
+			Pattern:  "/accounts",
+			Resource: "account_table",
+			Action:   []string{"list"},
+		},
+		xyprivhttp.Route{
+			Method:   http.MethodGet,
+			Pattern:  "/audit",
+			Resource: "audit_log",
+			Action:   []string{"read"},
+		},
+	)
+
+	var handler = routes.Middleware(func(r *http.Request) (xypriv.Subject, error) {
+		if u, ok := users[r.Header.Get("Authorization")]; ok {
+			return u, nil
+		}
+		return nil, nil
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var requests = []struct{ method, path, auth string }{
+		{http.MethodPost, "/accounts/user", ""},
+		{http.MethodPost, "/accounts/admin", "user-token"},
+		{http.MethodPost, "/accounts/admin", "admin-token"},
+		{http.MethodDelete, "/accounts", "admin-token"},
+		{http.MethodGet, "/audit", "admin-token"},
+	}
+
+	for _, req := range requests {
+		var r = httptest.NewRequest(req.method, req.path, nil)
+		r.Header.Set("Authorization", req.auth)
+
+		var w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		fmt.Println(req.method, req.path, w.Code)
+	}
+
+	// Requests never create abstract resources.
+	var _, ok = engine.FindAbstractResource("audit_log")
+	fmt.Println(ok)
+
+	routes.WriteRequirements(os.Stdout)
+
+	// Output:
+	// POST /accounts/user 200
+	// POST /accounts/admin 403
+	// POST /accounts/admin 200
+	// DELETE /accounts 404
+	// GET /audit 404
+	// false
+	// METHOD  PATTERN           RESOURCE       ACTION        LEVEL
+	// POST    /accounts/{role}  account_table  create_admin  9
+	// POST    /accounts/{role}  account_table  create_mod    7
+	// POST    /accounts/{role}  account_table  create_user   1
+	// GET     /accounts         account_table  list          1
+	// GET     /audit            audit_log      read          11
+}